@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+/*
+The flag package of the standard library only knows "-name value" flags, but scripts call gzip with grouped short
+options ("-dc", "-9k", "-S .z") and GNU long options ("--stdout", "--suffix=.z"), so the arguments are parsed by
+hand here, the getopt way:
+
+	-abc            the short options a, b and c
+	-Sx, -S x       the short option S with the value x
+	--name          a long option
+	--name=x        a long option with the value x (or --name x)
+	--              the end of the options, everything after it is a file name
+	-               a file name, standing for stdin
+*/
+
+// option is a command-line option, with its short and/or long name. set is called with its value (an empty string
+// for the options without one).
+type option struct {
+	short     byte   // 0 if there is no short name
+	long      string // "" if there is no long name
+	hasValue  bool
+	valueName string // for the usage, e.g. "suffix"
+	usage     string
+	set       func(value string) error
+}
+
+// parseArgs parses the options of args, calling their set function in order, and returns the other arguments
+// (the file names)
+func parseArgs(args []string, options []option) ([]string, error) {
+	var operands []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--":
+			return append(operands, args[i+1:]...), nil
+		case strings.HasPrefix(arg, "--"):
+			name, value, hasValue := arg[2:], "", false
+			if eq := strings.IndexByte(name, '='); eq >= 0 {
+				name, value, hasValue = name[:eq], name[eq+1:], true
+			}
+			opt := findLong(options, name)
+			if opt == nil {
+				return nil, fmt.Errorf("unrecognized option '--%s'", name)
+			}
+			if opt.hasValue && !hasValue {
+				if i+1 == len(args) {
+					return nil, fmt.Errorf("option '--%s' requires an argument", name)
+				}
+				i++
+				value = args[i]
+			} else if !opt.hasValue && hasValue {
+				return nil, fmt.Errorf("option '--%s' doesn't allow an argument", name)
+			}
+			if err := opt.set(value); err != nil {
+				return nil, fmt.Errorf("option '--%s': %w", name, err)
+			}
+		case strings.HasPrefix(arg, "-") && arg != "-":
+			for j := 1; j < len(arg); j++ {
+				opt := findShort(options, arg[j])
+				if opt == nil {
+					return nil, fmt.Errorf("invalid option -- '%c'", arg[j])
+				}
+				value := ""
+				if opt.hasValue {
+					// the rest of the argument is the value, or the next argument if there is nothing left
+					if value = arg[j+1:]; value == "" {
+						if i+1 == len(args) {
+							return nil, fmt.Errorf("option requires an argument -- '%c'", arg[j])
+						}
+						i++
+						value = args[i]
+					}
+					j = len(arg)
+				}
+				if err := opt.set(value); err != nil {
+					return nil, fmt.Errorf("option '-%c': %w", opt.short, err)
+				}
+			}
+		default:
+			operands = append(operands, arg)
+		}
+	}
+	return operands, nil
+}
+
+func findShort(options []option, short byte) *option {
+	for i := range options {
+		if options[i].short == short {
+			return &options[i]
+		}
+	}
+	return nil
+}
+
+func findLong(options []option, long string) *option {
+	for i := range options {
+		if options[i].long != "" && options[i].long == long {
+			return &options[i]
+		}
+	}
+	return nil
+}
+
+// printUsage prints one line per option, the way gzip --help does
+func printUsage(w io.Writer, options []option) {
+	for _, opt := range options {
+		if opt.usage == "" {
+			continue
+		}
+		names := ""
+		if opt.short != 0 {
+			names = "-" + string(opt.short)
+			if opt.long != "" {
+				names += ", "
+			}
+		} else {
+			names = "    "
+		}
+		if opt.long != "" {
+			names += "--" + opt.long
+		}
+		if opt.hasValue {
+			if opt.long != "" {
+				names += "=" + strings.ToUpper(opt.valueName)
+			} else {
+				names += " " + strings.ToUpper(opt.valueName)
+			}
+		}
+		fmt.Fprintf(w, "  %-26s %s\n", names, opt.usage)
+	}
+}
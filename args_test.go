@@ -0,0 +1,56 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseArgs(t *testing.T) {
+	var c, d, k bool
+	var suffix, level string
+	testOptions := []option{
+		{short: 'c', long: "stdout", set: boolValue(&c)},
+		{short: 'd', long: "decompress", set: boolValue(&d)},
+		{short: 'k', set: boolValue(&k)},
+		{short: 'S', long: "suffix", hasValue: true, set: stringValue(&suffix)},
+		{long: "level", hasValue: true, set: stringValue(&level)},
+	}
+
+	testCases := []struct {
+		name     string
+		args     []string
+		operands []string
+		c, d, k  bool
+		suffix   string
+		level    string
+	}{
+		{"grouped", []string{"-dc", "a.gz"}, []string{"a.gz"}, true, true, false, "", ""},
+		{"separate", []string{"a", "-d", "b", "-k"}, []string{"a", "b"}, false, true, true, "", ""},
+		{"long", []string{"--stdout", "--suffix=.z", "a"}, []string{"a"}, true, false, false, ".z", ""},
+		{"long value in next argument", []string{"--level", "9", "a"}, []string{"a"}, false, false, false, "", "9"},
+		{"attached value", []string{"-kS.z", "a"}, []string{"a"}, false, false, true, ".z", ""},
+		{"value in next argument", []string{"-S", "-z", "a"}, []string{"a"}, false, false, false, "-z", ""},
+		{"stdin", []string{"-c", "-"}, []string{"-"}, true, false, false, "", ""},
+		{"end of options", []string{"-d", "--", "-c", "--stdout"}, []string{"-c", "--stdout"}, false, true, false, "", ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c, d, k, suffix, level = false, false, false, "", ""
+			operands, err := parseArgs(tc.args, testOptions)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.operands, operands)
+			assert.Equal(t, tc.c, c)
+			assert.Equal(t, tc.d, d)
+			assert.Equal(t, tc.k, k)
+			assert.Equal(t, tc.suffix, suffix)
+			assert.Equal(t, tc.level, level)
+		})
+	}
+
+	for _, args := range [][]string{{"-x"}, {"--nope"}, {"-S"}, {"--suffix"}, {"--stdout=yes"}} {
+		_, err := parseArgs(args, testOptions)
+		assert.Error(t, err, "%q", args)
+	}
+}
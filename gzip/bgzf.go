@@ -0,0 +1,293 @@
+package gzip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+/*
+BGZF (blocked gzip, used by samtools/htslib for .bam files and tabix-indexed .vcf.gz files):
+
+A BGZF file is a plain multi-member gzip file, any gzip reader can inflate it. But every member (a "block") holds
+at most 64 KiB of compressed data, and its FEXTRA field has a "BC" subfield giving its size:
+
+	SI1 'B', SI2 'C', SLEN 2, BSIZE (2 bytes): size of the whole member minus 1
+
+So a reader can hop from one block to the next without inflating them, and a position in the uncompressed data can
+be given as a "virtual offset": the offset of the block in the file << 16 | the offset within the inflated block.
+The file ends with an empty block (the EOF marker), so a truncated file can be told apart from a complete one.
+*/
+
+const (
+	bgzfMaxBlockSize = 1 << 16 // BSIZE is 2 bytes
+	bgzfMaxDataSize  = 0xff00  // uncompressed bytes per block, like htslib: even stored, they fit in a block
+)
+
+// bgzfEOF is the empty block ending a BGZF file
+var bgzfEOF = []byte{
+	0x1f, 0x8b, 0x08, 0x04, 0x00, 0x00, 0x00, 0x00, 0x00, 0xff, 0x06, 0x00, 0x42, 0x43, 0x02, 0x00,
+	0x1b, 0x00, 0x03, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+}
+
+// VirtualOffset is a position in the uncompressed data of a BGZF file: the offset of a block in the file (48 bits)
+// and an offset within the inflated block (16 bits)
+type VirtualOffset uint64
+
+func NewVirtualOffset(compressed int64, uncompressed int) VirtualOffset {
+	return VirtualOffset(compressed)<<16 | VirtualOffset(uncompressed&0xffff)
+}
+
+// Compressed returns the offset of the block in the file
+func (v VirtualOffset) Compressed() int64 {
+	return int64(v >> 16)
+}
+
+// Uncompressed returns the offset within the inflated block
+func (v VirtualOffset) Uncompressed() int {
+	return int(v & 0xffff)
+}
+
+func (v VirtualOffset) String() string {
+	return fmt.Sprintf("%d:%d", v.Compressed(), v.Uncompressed())
+}
+
+// bgzfBlockSize finds BSIZE in the subfields of the extra field
+func bgzfBlockSize(extra []byte) (int, bool) {
+	field, err := ParseExtraField(extra)
+	if err != nil {
+		return 0, false
+	}
+	bsize, ok := field.Get('B', 'C')
+	if !ok || len(bsize) != 2 {
+		return 0, false
+	}
+	return int(binary.LittleEndian.Uint16(bsize)) + 1, true
+}
+
+// BGZFWriter is an io.WriteCloser compressing what is written to it into a BGZF file
+type BGZFWriter struct {
+	w       io.Writer
+	level   int
+	buf     []byte // data of the next block
+	address int64  // offset of the next block in the file
+	err     error
+}
+
+// NewBGZFWriter creates a new BGZFWriter writing the BGZF file to w, with the default compression level
+func NewBGZFWriter(w io.Writer) *BGZFWriter {
+	z, _ := NewBGZFWriterLevel(w, DefaultCompression)
+	return z
+}
+
+// NewBGZFWriterLevel is like NewBGZFWriter, with the compression level of NewWriterLevel
+func NewBGZFWriterLevel(w io.Writer, level int) (*BGZFWriter, error) {
+	if _, err := NewWriterLevel(io.Discard, level); err != nil {
+		return nil, err
+	}
+	return &BGZFWriter{w: w, level: level, buf: make([]byte, 0, bgzfMaxDataSize)}, nil
+}
+
+// Write compresses p, a block is written out every 65280 bytes
+func (z *BGZFWriter) Write(p []byte) (int, error) {
+	if z.err != nil {
+		return 0, z.err
+	}
+	n := 0
+	for len(p) > 0 {
+		chunk := bgzfMaxDataSize - len(z.buf)
+		if chunk > len(p) {
+			chunk = len(p)
+		}
+		z.buf = append(z.buf, p[:chunk]...)
+		p = p[chunk:]
+		n += chunk
+		if len(z.buf) == bgzfMaxDataSize {
+			if z.err = z.Flush(); z.err != nil {
+				return n, z.err
+			}
+		}
+	}
+	return n, nil
+}
+
+// Tell returns the virtual offset of the next byte to be written, e.g. to index the records of the file
+func (z *BGZFWriter) Tell() VirtualOffset {
+	return NewVirtualOffset(z.address, len(z.buf))
+}
+
+// Flush writes the data written so far as a block (if there is any), so the next byte starts a new block
+func (z *BGZFWriter) Flush() error {
+	if z.err != nil || len(z.buf) == 0 {
+		return z.err
+	}
+	block, err := compressBGZFBlock(z.buf, z.level)
+	if err == nil {
+		_, err = z.w.Write(block)
+	}
+	z.address += int64(len(block))
+	z.buf = z.buf[:0]
+	z.err = err
+	return err
+}
+
+// Close writes the last block and the EOF marker, it does not close the underlying io.Writer
+func (z *BGZFWriter) Close() error {
+	if z.err == errWriterClosed {
+		return nil
+	}
+	if err := z.Flush(); err != nil {
+		return err
+	}
+	if _, z.err = z.w.Write(bgzfEOF); z.err != nil {
+		return z.err
+	}
+	z.err = errWriterClosed
+	return nil
+}
+
+// compressBGZFBlock compresses data into a gzip member with the BC subfield
+func compressBGZFBlock(data []byte, level int) ([]byte, error) {
+	for {
+		var out bytes.Buffer
+		writer, err := NewWriterLevel(&out, level)
+		if err != nil {
+			return nil, err
+		}
+		// BSIZE is filled in once the size is known
+		writer.Header.Subfields = ExtraField{{ID: [2]byte{'B', 'C'}, Data: []byte{0, 0}}}
+		if _, err := writer.Write(data); err != nil {
+			return nil, err
+		}
+		if err := writer.Close(); err != nil {
+			return nil, err
+		}
+		block := out.Bytes()
+		if len(block) > bgzfMaxBlockSize && level != NoCompression {
+			level = NoCompression // the data doesn't compress at all, stored blocks always fit
+			continue
+		}
+		// the extra field is right after the 10 bytes of the header and XLEN
+		binary.LittleEndian.PutUint16(block[16:], uint16(len(block)-1))
+		return block, nil
+	}
+}
+
+// BGZFReader is an io.Reader inflating a BGZF file one block at a time, which can seek to a virtual offset
+type BGZFReader struct {
+	r       io.ReadSeeker
+	block   []byte // inflated data of the current block
+	pos     int    // position in block
+	address int64  // offset of the current block in the file
+	next    int64  // offset of the next block
+	err     error
+}
+
+// NewBGZFReader creates a new BGZFReader reading the BGZF file from r, starting at its current position.
+// The first block is read right away, to check that it is a BGZF file.
+func NewBGZFReader(r io.ReadSeeker) (*BGZFReader, error) {
+	offset, err := r.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return nil, err
+	}
+	z := &BGZFReader{r: r, address: offset, next: offset}
+	if err := z.readBlock(); err != nil && err != io.EOF {
+		return nil, err
+	}
+	return z, nil
+}
+
+// readBlock reads and inflates the block at z.next, it returns io.EOF if there is no block left
+func (z *BGZFReader) readBlock() error {
+	// the header up to XLEN, then the extra field which has the size of the rest
+	raw := make([]byte, 12)
+	if _, err := io.ReadFull(z.r, raw); err != nil {
+		if err == io.EOF {
+			return io.EOF
+		}
+		return z.blockError(err)
+	}
+	if raw[0] != 0x1f || raw[1] != 0x8b {
+		return z.blockError(ErrBadMagic)
+	}
+	if raw[3]&FEXTRA == 0 {
+		return z.blockError(ErrNotBGZF)
+	}
+	raw = append(raw, make([]byte, binary.LittleEndian.Uint16(raw[10:]))...)
+	if _, err := io.ReadFull(z.r, raw[12:]); err != nil {
+		return z.blockError(err)
+	}
+	size, ok := bgzfBlockSize(raw[12:])
+	if !ok || size < len(raw) {
+		return z.blockError(ErrNotBGZF)
+	}
+	headerLength := len(raw)
+	raw = append(raw, make([]byte, size-headerLength)...)
+	if _, err := io.ReadFull(z.r, raw[headerLength:]); err != nil {
+		return z.blockError(err)
+	}
+
+	reader, err := NewReader(bytes.NewReader(raw))
+	if err != nil {
+		return z.blockError(err)
+	}
+	reader.Multistream(false)
+	if z.block, err = io.ReadAll(reader); err != nil {
+		return z.blockError(err)
+	}
+	z.address, z.next, z.pos = z.next, z.next+int64(size), 0
+	return nil
+}
+
+func (z *BGZFReader) blockError(err error) error {
+	if err == io.EOF {
+		err = ErrUnexpectedEOF
+	}
+	return fmt.Errorf("bgzf: block at offset %d: %w", z.next, err)
+}
+
+func (z *BGZFReader) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	for z.pos == len(z.block) {
+		if z.err != nil {
+			return 0, z.err
+		}
+		z.err = z.readBlock()
+	}
+	n := copy(p, z.block[z.pos:])
+	z.pos += n
+	return n, nil
+}
+
+// Tell returns the virtual offset of the next byte to be read
+func (z *BGZFReader) Tell() VirtualOffset {
+	return NewVirtualOffset(z.address, z.pos)
+}
+
+// SeekVirtual moves to a virtual offset, as returned by Tell (of the BGZFReader or the BGZFWriter)
+func (z *BGZFReader) SeekVirtual(v VirtualOffset) error {
+	if _, err := z.r.Seek(v.Compressed(), io.SeekStart); err != nil {
+		return err
+	}
+	z.address, z.next, z.block, z.err = v.Compressed(), v.Compressed(), nil, nil
+	if err := z.readBlock(); err != nil && err != io.EOF {
+		z.err = err
+		return err
+	}
+	if v.Uncompressed() > len(z.block) {
+		z.err = fmt.Errorf("bgzf: virtual offset %v is past the end of its block", v)
+		return z.err
+	}
+	z.pos = v.Uncompressed()
+	return nil
+}
+
+// Close releases the current block, it does not close the underlying io.ReadSeeker
+func (z *BGZFReader) Close() error {
+	z.block, z.pos = nil, 0
+	z.err = errClosed
+	return nil
+}
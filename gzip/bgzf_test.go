@@ -0,0 +1,90 @@
+package gzip
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBGZFRoundTrip(t *testing.T) {
+	data := append(bytes.Repeat(testInputs()["feynman.txt"], 4), testInputs()["random"]...)
+	var out bytes.Buffer
+	writer := NewBGZFWriter(&out)
+	var offsets []VirtualOffset // virtual offset of every 10000th byte
+	for i := 0; i < len(data); i += 10000 {
+		offsets = append(offsets, writer.Tell())
+		end := i + 10000
+		if end > len(data) {
+			end = len(data)
+		}
+		_, err := writer.Write(data[i:end])
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, writer.Close())
+	compressed := out.Bytes()
+	assert.True(t, bytes.HasSuffix(compressed, bgzfEOF))
+
+	// it is a regular multi-member gzip file
+	inflated, err := readGzipFile(bytes.NewReader(compressed))
+	assert.NoError(t, err)
+	assert.True(t, bytes.Equal(data, inflated))
+
+	reader, err := NewBGZFReader(bytes.NewReader(compressed))
+	assert.NoError(t, err)
+	inflated, err = io.ReadAll(reader)
+	assert.NoError(t, err)
+	assert.True(t, bytes.Equal(data, inflated))
+
+	for i := len(offsets) - 1; i >= 0; i-- {
+		assert.NoError(t, reader.SeekVirtual(offsets[i]))
+		assert.Equal(t, offsets[i], reader.Tell())
+		p := make([]byte, 100)
+		n, err := io.ReadFull(reader, p)
+		if i*10000+100 <= len(data) {
+			assert.NoError(t, err)
+		}
+		assert.True(t, bytes.Equal(data[i*10000:i*10000+n], p[:n]), "reading at %v", offsets[i])
+	}
+}
+
+func TestBGZFBlocks(t *testing.T) {
+	data := testInputs()["random"] // doesn't compress, the blocks are stored
+	var out bytes.Buffer
+	writer, err := NewBGZFWriterLevel(&out, BestSpeed)
+	assert.NoError(t, err)
+	_, err = writer.Write(data)
+	assert.NoError(t, err)
+	assert.NoError(t, writer.Close())
+
+	compressed := out.Bytes()
+	blocks := 0
+	for len(compressed) > 0 {
+		size, ok := bgzfBlockSize(compressed[12:18])
+		assert.True(t, ok)
+		assert.LessOrEqual(t, size, bgzfMaxBlockSize)
+		compressed = compressed[size:]
+		blocks++
+	}
+	assert.Equal(t, (len(data)+bgzfMaxDataSize-1)/bgzfMaxDataSize+1, blocks)
+}
+
+func TestBGZFNotBGZF(t *testing.T) {
+	_, err := NewBGZFReader(bytes.NewReader(compressForTest(t, []byte("hello"))))
+	assert.ErrorIs(t, err, ErrNotBGZF)
+
+	reader, err := NewBGZFReader(bytes.NewReader(bgzfEOF))
+	assert.NoError(t, err)
+	n, err := reader.Read(make([]byte, 10))
+	assert.Equal(t, 0, n)
+	assert.Equal(t, io.EOF, err)
+	assert.Error(t, reader.SeekVirtual(NewVirtualOffset(0, 1)))
+}
+
+func TestVirtualOffset(t *testing.T) {
+	v := NewVirtualOffset(123456789, 4321)
+	assert.Equal(t, int64(123456789), v.Compressed())
+	assert.Equal(t, 4321, v.Uncompressed())
+	assert.Equal(t, "123456789:4321", v.String())
+}
@@ -0,0 +1,181 @@
+package gzip
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+const (
+	bitstreamBufferSize = 4096
+	maxReadBits         = 56 // at most 56 bits can be peeked or read at once
+)
+
+// bitstream reads the input bit by bit (or many bits at once). The input is buffered, and loaded 8 bytes at a
+// time into a 64-bit accumulator, from which the bits are consumed.
+type bitstream struct {
+	source io.Reader
+	in     []byte // buffered input, in[inPos:inEnd] hasn't been loaded into bits yet
+	inPos  int
+	inEnd  int
+	err    error // error returned by source, reported once the buffered input runs out
+
+	bits   uint64 // bits loaded but not consumed yet, the next bit is the LSB
+	nbits  uint   // number of bits in bits
+	offset int64  // number of bytes loaded into bits (or handed out by Read)
+}
+
+// fillInput reads more input from source, if everything buffered has been loaded
+func fillInput(stream *bitstream) error {
+	if stream.in == nil {
+		stream.in = make([]byte, bitstreamBufferSize)
+	}
+	for stream.inPos == stream.inEnd {
+		if stream.err != nil {
+			return stream.err
+		}
+		var n int
+		n, stream.err = stream.source.Read(stream.in)
+		stream.inPos, stream.inEnd = 0, n
+	}
+	return nil
+}
+
+// refill loads the buffered input into bits until there are at least count bits (at most 64) available.
+// It returns the error of source (e.g. io.EOF) if the input runs out before that.
+func refill(stream *bitstream, count uint) error {
+	for stream.nbits < count {
+		if stream.inEnd-stream.inPos >= 8 {
+			// bulk load: as many whole bytes as fit in bits
+			loaded := (64 - stream.nbits) / 8
+			chunk := binary.LittleEndian.Uint64(stream.in[stream.inPos:])
+			if loaded < 8 {
+				chunk &= 1<<(8*loaded) - 1
+			}
+			stream.bits |= chunk << stream.nbits
+			stream.nbits += 8 * loaded
+			stream.inPos += int(loaded)
+			stream.offset += int64(loaded)
+			continue
+		}
+		if err := fillInput(stream); err != nil {
+			return err
+		}
+		stream.bits |= uint64(stream.in[stream.inPos]) << stream.nbits
+		stream.nbits += 8
+		stream.inPos++
+		stream.offset++
+	}
+	return nil
+}
+
+// nextBit is little endian (LSB to MSB)
+func nextBit(stream *bitstream) (byte, error) {
+	if err := refill(stream, 1); err != nil {
+		return 0, decodeError(stream, err)
+	}
+	bit := byte(stream.bits & 1)
+	consumeBits(stream, 1)
+	return bit, nil
+}
+
+// readBitsInv read in little-endian form but interpreted in big-endian form
+func readBitsInv(stream *bitstream, count int) (value int, err error) {
+	if count > maxReadBits {
+		panic("at most 56 bits can be read at once")
+	}
+	if err := refill(stream, uint(count)); err != nil {
+		return 0, decodeError(stream, err)
+	}
+	value = int(stream.bits & (1<<count - 1))
+	consumeBits(stream, uint(count))
+	return value, nil
+}
+
+// peekBits returns the next count bits (at most 56) without consuming them, along with how many of them are
+// actually available: there may be fewer than count bits left at the end of the input, the missing bits are 0.
+func peekBits(stream *bitstream, count uint) (value uint64, available uint, err error) {
+	if err := refill(stream, count); err != nil && err != io.EOF {
+		return 0, 0, decodeError(stream, err)
+	}
+	available = stream.nbits
+	if available > count {
+		available = count
+	}
+	return stream.bits & (1<<count - 1), available, nil
+}
+
+// consumeBits drops count bits that have been peeked
+func consumeBits(stream *bitstream, count uint) {
+	stream.bits >>= count
+	stream.nbits -= count
+}
+
+// bitOffset returns the absolute position of the next bit to be read, in bits from the start of the input
+func bitOffset(stream *bitstream) int64 {
+	return stream.offset*8 - int64(stream.nbits)
+}
+
+// bitPosition returns the offset of the byte holding the next bit to be read, and the position of that bit
+func bitPosition(stream *bitstream) (offset int64, bit int) {
+	position := bitOffset(stream)
+	return position / 8, int(position % 8)
+}
+
+// alignToByte discards the remaining bits of the current byte, so the next read starts at a byte boundary
+func alignToByte(stream *bitstream) {
+	consumeBits(stream, stream.nbits%8)
+}
+
+// atEOF reports whether the whole input has been consumed, the stream must be aligned to a byte boundary
+func atEOF(stream *bitstream) bool {
+	return stream.nbits == 0 && fillInput(stream) == io.EOF
+}
+
+// readBytes reads count raw bytes, the stream must be aligned to a byte boundary (see alignToByte)
+func readBytes(stream *bitstream, count int) ([]byte, error) {
+	bytes := make([]byte, count)
+	if _, err := io.ReadFull(stream, bytes); err != nil {
+		return nil, decodeError(stream, err)
+	}
+	return bytes, nil
+}
+
+// Read implements io.Reader, handing back the bytes that haven't been consumed as bits yet (e.g. the gzip
+// trailer that follows the deflate stream). The stream must be aligned to a byte boundary (see alignToByte).
+func (stream *bitstream) Read(p []byte) (n int, err error) {
+	if stream.nbits%8 != 0 {
+		return 0, errors.New("bitstream is not byte-aligned")
+	}
+	// the bytes already loaded into bits come first
+	for n < len(p) && stream.nbits > 0 {
+		p[n] = byte(stream.bits)
+		consumeBits(stream, 8)
+		n++
+	}
+	if n == len(p) {
+		return n, nil
+	}
+	if n == 0 {
+		if err := fillInput(stream); err != nil {
+			return 0, err
+		}
+	}
+	copied := copy(p[n:], stream.in[stream.inPos:stream.inEnd])
+	stream.inPos += copied
+	stream.offset += int64(copied)
+	return n + copied, nil
+}
+
+func helperBitStringToBytes(bits string) []byte {
+	bytes := make([]byte, 0)
+	for i, c := range bits {
+		if i%8 == 0 {
+			bytes = append(bytes, 0x00)
+		}
+		if string(c) == "1" {
+			bytes[len(bytes)-1] |= 1 << (i % 8)
+		}
+	}
+	return bytes
+}
@@ -0,0 +1,166 @@
+package gzip
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNextBit(t *testing.T) {
+	source := bytes.NewReader([]byte{0xA3, 0xF2})
+	stream := &bitstream{
+		source: source,
+	}
+	expected := []byte{
+		// 3
+		0x01,
+		0x01,
+		0x00,
+		0x00,
+
+		// A
+		0x00,
+		0x01,
+		0x00,
+		0x01,
+
+		// 2
+		0x00,
+		0x01,
+		0x00,
+		0x00,
+
+		// F (only the first bit)
+		0x01,
+	}
+	for i, expBit := range expected {
+		bit, err := nextBit(stream)
+		assert.NoError(t, err)
+		assert.Equal(t, expBit, bit, fmt.Sprintf("{%d}-th bit", i+1))
+	}
+}
+
+func TestReadBitsInv(t *testing.T) {
+	//   10100011 11110010
+	// 1    _____
+	// 2 ___            __
+	// 3          ______
+	source := bytes.NewReader([]byte{0xA3, 0xF2})
+	stream := &bitstream{
+		source: source,
+	}
+	expected := []struct {
+		length    int
+		bitsValue int
+	}{
+		{5, 0b00011},
+		{5, 0b10101},
+		{6, 0b111100},
+	}
+	for _, tc := range expected {
+		bitsValue, err := readBitsInv(stream, tc.length)
+		assert.NoError(t, err)
+		assert.Equal(t, tc.bitsValue, bitsValue, tc)
+	}
+}
+
+func TestReadBitsInvUnexpectedEOF(t *testing.T) {
+	stream := &bitstream{
+		source: bytes.NewReader([]byte{0xA3}),
+	}
+	_, err := readBitsInv(stream, 6)
+	assert.NoError(t, err)
+	_, err = readBitsInv(stream, 6)
+	assert.ErrorIs(t, err, ErrUnexpectedEOF)
+
+	// the error points at the start of the truncated value
+	var decodeErr *DecodeError
+	assert.ErrorAs(t, err, &decodeErr)
+	assert.Equal(t, int64(0), decodeErr.Offset)
+	assert.Equal(t, 6, decodeErr.Bit)
+}
+
+func TestReadBitsInvWide(t *testing.T) {
+	stream := &bitstream{
+		source: bytes.NewReader([]byte{0x01, 0x23, 0x45, 0x67, 0x89, 0xAB, 0xCD, 0xEF, 0xFF}),
+	}
+	value, err := readBitsInv(stream, 4)
+	assert.NoError(t, err)
+	assert.Equal(t, 0x1, value)
+	value, err = readBitsInv(stream, 40)
+	assert.NoError(t, err)
+	assert.Equal(t, 0xB8_9674_5230, value)
+	assert.Equal(t, int64(44), bitOffset(stream))
+
+	peeked, available, err := peekBits(stream, 32)
+	assert.NoError(t, err)
+	assert.Equal(t, uint(28), available, "only 28 bits left")
+	assert.Equal(t, uint64(0xFFEFCDA), peeked)
+	consumeBits(stream, 4)
+	assert.Equal(t, int64(48), bitOffset(stream))
+}
+
+func TestBitstreamRefillAcrossReads(t *testing.T) {
+	// more than the buffer, handed out one byte at a time by the source
+	input := make([]byte, 3*bitstreamBufferSize+5)
+	for i := range input {
+		input[i] = byte(i * 7)
+	}
+	stream := &bitstream{source: iotest.OneByteReader(bytes.NewReader(input))}
+	for i := range input {
+		value, err := readBitsInv(stream, 8)
+		assert.NoError(t, err)
+		if !assert.Equal(t, int(input[i]), value, i) {
+			break
+		}
+	}
+	assert.True(t, atEOF(stream))
+}
+
+func TestReadAfterAlignToByte(t *testing.T) {
+	stream := &bitstream{
+		source: bytes.NewReader([]byte{0xA3, 0xF2, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0A}),
+	}
+	bits, err := readBitsInv(stream, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, 0b011, bits)
+
+	// the rest of 0xA3 is discarded
+	alignToByte(stream)
+	raw, err := readBytes(stream, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0xF2, 0x01}, raw)
+
+	bits, err = readBitsInv(stream, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, 0b10, bits)
+	offset, bit := bitPosition(stream)
+	assert.Equal(t, int64(3), offset)
+	assert.Equal(t, 2, bit)
+
+	_, err = stream.Read(make([]byte, 1))
+	assert.Error(t, err, "reading bytes in the middle of a byte")
+
+	// the bytes loaded in bulk into the accumulator are handed back
+	alignToByte(stream)
+	rest, err := io.ReadAll(stream)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0A}, rest)
+	assert.True(t, atEOF(stream))
+}
+
+func TestHelperBitStringToBytes(t *testing.T) {
+	bits := "11111011101011111101010001101100011100"
+	expectedBytes := []byte{
+		0b1101_1111,
+		0b1111_0101,
+		0b0010_1011,
+		0b0011_0110,
+		0b00_1110,
+	}
+	assert.Equal(t, expectedBytes, helperBitStringToBytes(bits))
+}
@@ -0,0 +1,84 @@
+package gzip
+
+import (
+	"errors"
+	"io"
+)
+
+const bitWriterBufferSize = 4096
+
+// bitWriter is the counterpart of bitstream: the bits are accumulated LSB first in a 64-bit buffer, and written
+// out a byte at a time into out, which is written to dest once it is full.
+type bitWriter struct {
+	dest io.Writer
+	out  []byte
+	err  error // first error returned by dest, nothing is written after that
+
+	bits   uint64 // bits not written to out yet, the first one is the LSB
+	nbits  uint   // number of bits in bits, always less than 8 between two calls
+	offset int64  // number of bytes written to out so far
+}
+
+func newBitWriter(dest io.Writer) *bitWriter {
+	return &bitWriter{dest: dest, out: make([]byte, 0, bitWriterBufferSize)}
+}
+
+// writeBitsInv writes the count (at most 56) lowest bits of value, LSB first, the same way readBitsInv reads them
+func writeBitsInv(writer *bitWriter, value uint64, count uint) {
+	if count > maxReadBits {
+		panic("can't write more than 56 bits at once")
+	}
+	writer.bits |= (value & (1<<count - 1)) << writer.nbits
+	writer.nbits += count
+	for writer.nbits >= 8 {
+		writer.out = append(writer.out, byte(writer.bits))
+		writer.bits >>= 8
+		writer.nbits -= 8
+		writer.offset++
+	}
+	if len(writer.out) >= bitWriterBufferSize {
+		flushWriter(writer)
+	}
+}
+
+// alignWriter pads the current byte with zeros, so the next bits start at a byte boundary
+func alignWriter(writer *bitWriter) {
+	if writer.nbits > 0 {
+		writeBitsInv(writer, 0, 8-writer.nbits)
+	}
+}
+
+// flushWriter writes the buffered bytes to dest. The bits of an incomplete byte stay in the buffer.
+func flushWriter(writer *bitWriter) error {
+	if writer.err == nil && len(writer.out) > 0 {
+		_, writer.err = writer.dest.Write(writer.out)
+	}
+	writer.out = writer.out[:0]
+	return writer.err
+}
+
+// Write writes whole bytes, it can only be used at a byte boundary (after alignWriter).
+// It allows to write the headers with binary.Write.
+func (writer *bitWriter) Write(p []byte) (int, error) {
+	if writer.nbits != 0 {
+		return 0, errors.New("bitWriter: can't write bytes in the middle of a byte")
+	}
+	if writer.err != nil {
+		return 0, writer.err
+	}
+	if len(writer.out)+len(p) > bitWriterBufferSize {
+		if err := flushWriter(writer); err != nil {
+			return 0, err
+		}
+	}
+	writer.offset += int64(len(p))
+	if len(p) > bitWriterBufferSize {
+		// too big to be buffered
+		if _, writer.err = writer.dest.Write(p); writer.err != nil {
+			return 0, writer.err
+		}
+		return len(p), nil
+	}
+	writer.out = append(writer.out, p...)
+	return len(p), nil
+}
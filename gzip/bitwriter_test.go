@@ -0,0 +1,43 @@
+package gzip
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBitWriterRoundTrip(t *testing.T) {
+	var out bytes.Buffer
+	writer := newBitWriter(&out)
+	writeBitsInv(writer, 0b101, 3)
+	writeBitsInv(writer, 0xABCDEF, 24)
+	writeBitsInv(writer, 1<<55|1, 56)
+	alignWriter(writer)
+	_, err := writer.Write([]byte{0x12, 0x34})
+	assert.NoError(t, err)
+	assert.NoError(t, flushWriter(writer))
+	assert.Equal(t, int64(out.Len()), writer.offset)
+
+	stream := &bitstream{source: &out}
+	value, err := readBitsInv(stream, 3)
+	assert.NoError(t, err)
+	assert.Equal(t, 0b101, value)
+	value, err = readBitsInv(stream, 24)
+	assert.NoError(t, err)
+	assert.Equal(t, 0xABCDEF, value)
+	value, err = readBitsInv(stream, 56)
+	assert.NoError(t, err)
+	assert.Equal(t, 1<<55|1, value)
+	alignToByte(stream)
+	rest, err := readBytes(stream, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0x12, 0x34}, rest)
+}
+
+func TestBitWriterUnaligned(t *testing.T) {
+	writer := newBitWriter(&bytes.Buffer{})
+	writeBitsInv(writer, 1, 1)
+	_, err := writer.Write([]byte{0x12})
+	assert.Error(t, err)
+}
@@ -0,0 +1,342 @@
+package gzip
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	deflaterBufferSize = 2 * windowSize
+	maxBlockTokens     = 1 << 14 // tokens per block, the block is written out once there are that many
+	maxStoredBlock     = 0xFFFF  // LEN is 2 bytes
+)
+
+// The compression levels, from 0 (no compression, only stored blocks) to 9, like gzip -1 ... gzip -9.
+// OptimalCompression goes further by searching the cheapest way to encode each block (like Zopfli), it is about
+// a hundred times slower than BestCompression, for a few percent smaller output.
+const (
+	NoCompression      = 0
+	BestSpeed          = 1
+	BestCompression    = 9
+	OptimalCompression = 10
+	DefaultCompression = -1 // level 6
+)
+
+// Strategy tunes the encoder for the kind of data being compressed (the zlib strategies)
+type Strategy int
+
+const (
+	DefaultStrategy Strategy = iota
+	// Filtered is for data made of small values with a somewhat random distribution (e.g. filtered images): short
+	// back-pointers are not worth it, so only matches longer than 5 bytes are used, the rest is left to the huffman
+	// codes.
+	Filtered
+	// HuffmanOnly doesn't look for back-pointers at all, which is fast and as good for data that doesn't repeat.
+	HuffmanOnly
+	// RLE only looks for back-pointers at a distance of 1 (runs of the same byte), as found in images.
+	RLE
+	// Stored doesn't compress at all, the data is written in stored blocks.
+	Stored
+)
+
+var errInvalidLevel = errors.New("gzip: invalid compression level")
+
+// compressionConfig is how hard each level tries to find good matches
+type compressionConfig struct {
+	optimal    bool // find the cheapest tokens with optimalParse, instead of the matches found on the way
+	lazy       bool // look for a longer match at the next position before taking a match
+	maxLazy    int  // don't bother looking for a longer match if the match is at least that long
+	niceLength int  // stop searching the chain once a match is that long
+	maxChain   int  // number of positions visited in the hash chain
+}
+
+var compressionLevels = []compressionConfig{
+	0: {},
+	1: {false, false, 0, 8, 4},
+	2: {false, false, 0, 16, 8},
+	3: {false, false, 0, 32, 32},
+	4: {false, true, 4, 16, 16},
+	5: {false, true, 16, 32, 32},
+	6: {false, true, 16, 128, 128},
+	7: {false, true, 32, 128, 256},
+	8: {false, true, 128, 258, 1024},
+	9: {false, true, 258, 258, 4096},
+	// beyond gzip -9: optimal parsing, this is really slow
+	10: {true, false, 0, 258, 8192},
+}
+
+// deflater is the encoder: it turns the input into tokens (literals and back-pointers) with the matcher, and
+// writes them out as huffman blocks.
+//
+// The input goes into buf, which keeps up to windowSize bytes of history (what back-pointers can refer to) in
+// front of the bytes waiting to be encoded. Once buf is full, it slides down by windowSize.
+type deflater struct {
+	writer   *bitWriter
+	config   compressionConfig
+	strategy Strategy
+	matcher  matcher
+
+	buf        []byte
+	pos        int // buf[:pos] has been turned into tokens
+	hashed     int // buf[:hashed] has been inserted into the hash chains
+	blockStart int // buf[blockStart:pos] is the input of the tokens of the current block
+	tokens     []token
+
+	// the match found at the next position, while looking for a longer match (lazy matching)
+	nextPos, nextLength, nextDist int
+
+	literals, distances *huffmanEncoder // the fixed codes
+}
+
+func newDeflater(writer *bitWriter, level int, strategy Strategy) (*deflater, error) {
+	if level == DefaultCompression {
+		level = 6
+	}
+	if level < NoCompression || level > OptimalCompression {
+		return nil, fmt.Errorf("%w: %d", errInvalidLevel, level)
+	}
+	if strategy < DefaultStrategy || strategy > Stored {
+		return nil, fmt.Errorf("gzip: invalid strategy %d", strategy)
+	}
+	if level == NoCompression {
+		strategy = Stored
+	}
+	config := compressionLevels[level]
+	if config.optimal && strategy != DefaultStrategy {
+		// the strategies restrict the matches, which is at odds with looking for the cheapest ones
+		config = compressionLevels[BestCompression]
+	}
+	// the fixed codes are always valid, no error to handle
+	literals, _ := newHuffmanEncoder(fixedLiteralRanges)
+	distances, _ := newHuffmanEncoder(fixedDistanceRanges)
+	return &deflater{
+		writer:    writer,
+		config:    config,
+		strategy:  strategy,
+		matcher:   newMatcher(config.maxChain, config.niceLength),
+		buf:       make([]byte, 0, deflaterBufferSize),
+		tokens:    make([]token, 0, maxBlockTokens),
+		nextPos:   -1,
+		literals:  literals,
+		distances: distances,
+	}, nil
+}
+
+// write encodes p, apart from the last bytes which are kept as lookahead until more input comes (or close)
+func (d *deflater) write(p []byte) error {
+	for len(p) > 0 {
+		if len(d.buf) == cap(d.buf) {
+			// the block can't refer to the input that is about to be dropped
+			d.writeBlock(false)
+			d.slide()
+		}
+		n := copy(d.buf[len(d.buf):cap(d.buf)], p)
+		d.buf = d.buf[:len(d.buf)+n]
+		p = p[n:]
+		// keep maxMatch bytes of lookahead so the matches aren't cut short
+		d.encode(len(d.buf) - maxMatch)
+	}
+	return d.writer.err
+}
+
+// close encodes the rest of the input and writes the final block, padded to a byte boundary
+func (d *deflater) close() error {
+	d.encode(len(d.buf))
+	d.writeBlock(true)
+	alignWriter(d.writer)
+	return flushWriter(d.writer)
+}
+
+// flush encodes all the input so far, and ends with an empty stored block so the output stops at a byte boundary
+// (like zlib's Z_SYNC_FLUSH). The deflate stream isn't over, more blocks can follow.
+func (d *deflater) flush() error {
+	d.encode(len(d.buf))
+	d.writeBlock(false)
+	writeStoredBlocks(d.writer, nil, false)
+	return flushWriter(d.writer)
+}
+
+// preset fills the history with a dictionary: back-pointers can refer to it, but it isn't part of the output.
+// It must be called before the first write.
+func (d *deflater) preset(dict []byte) {
+	if len(dict) > windowSize {
+		dict = dict[len(dict)-windowSize:]
+	}
+	d.buf = append(d.buf, dict...)
+	d.pos, d.blockStart = len(dict), len(dict)
+	// hashed stays at 0, the dictionary is inserted into the hash chains along with the input
+}
+
+// slide drops the oldest windowSize bytes of buf, they are too far back to be referred to
+func (d *deflater) slide() {
+	copy(d.buf, d.buf[windowSize:])
+	d.buf = d.buf[:len(d.buf)-windowSize]
+	d.pos -= windowSize
+	d.hashed -= windowSize
+	d.blockStart -= windowSize
+	d.nextPos -= windowSize
+	slideMatcher(&d.matcher)
+}
+
+// encode turns the input into tokens until end, writing out a block every maxBlockTokens tokens
+func (d *deflater) encode(end int) {
+	if d.strategy == Stored || d.config.optimal {
+		// the input is stored as it is, or parsed all at once when the block is written
+		maxBlock := maxStoredBlock
+		if d.config.optimal {
+			maxBlock = maxOptimalBlock
+		}
+		for d.pos < end {
+			d.pos = end
+			if d.pos-d.blockStart > maxBlock {
+				d.pos = d.blockStart + maxBlock
+				d.writeBlock(false)
+			}
+		}
+		return
+	}
+	for d.pos < end {
+		length, dist := d.findMatch(d.pos)
+		if d.config.lazy && length > 0 && length < d.config.maxLazy && d.pos+1 < len(d.buf) {
+			// maybe skipping this byte gives a longer match
+			nextLength, nextDist := d.findMatch(d.pos + 1)
+			d.nextPos, d.nextLength, d.nextDist = d.pos+1, nextLength, nextDist
+			if nextLength > length {
+				length = 0
+			}
+		}
+		if length > 0 {
+			d.tokens = append(d.tokens, matchToken(length, dist))
+		} else {
+			d.tokens = append(d.tokens, literalToken(d.buf[d.pos]))
+			length = 1
+		}
+		d.pos += length
+		if len(d.tokens) == maxBlockTokens {
+			d.writeBlock(false)
+		}
+	}
+}
+
+// findMatch returns the match to use at pos (length 0 if there is none) according to the strategy
+func (d *deflater) findMatch(pos int) (length int, dist int) {
+	if pos == d.nextPos {
+		return d.nextLength, d.nextDist // already found while lazy matching
+	}
+	switch d.strategy {
+	case HuffmanOnly:
+		return 0, 0
+	case RLE:
+		return findRun(d.buf, pos, maxMatch), 1
+	}
+	for ; d.hashed < pos; d.hashed++ {
+		insertHash(&d.matcher, d.buf, d.hashed)
+	}
+	length, dist = findMatch(&d.matcher, d.buf, pos, maxMatch)
+	if d.strategy == Filtered && length <= 5 {
+		return 0, 0
+	}
+	return length, dist
+}
+
+// writeBlock writes the tokens of the current block
+func (d *deflater) writeBlock(final bool) {
+	if d.pos == d.blockStart && !final {
+		return
+	}
+	raw := d.buf[d.blockStart:d.pos]
+	switch {
+	case d.strategy == Stored:
+		writeStoredBlocks(d.writer, raw, final)
+	case d.config.optimal:
+		d.writeOptimalBlocks(d.blockStart, d.pos, final)
+	default:
+		d.writeTokenBlock(d.tokens, raw, final)
+	}
+	d.blockStart = d.pos
+	d.tokens = d.tokens[:0]
+}
+
+// blockType chooses how to write the tokens (the input of which is raw), whichever is the smallest of:
+// - a stored block (block type 0b00), for data that doesn't compress
+// - a huffman block with dynamic codes (block type 0b10)
+// - a huffman block with the fixed codes (block type 0b01), for small blocks as they don't have to describe the
+// codes
+// It returns the block type, its size in bits (not counting the 3 bits of the block header) and the header of the
+// dynamic codes.
+func (d *deflater) blockType(tokens []token, raw []byte) (blockType int, bits int, header *dynamicHeader) {
+	header = buildDynamicHeader(tokenFrequencies(tokens))
+	dynamicBits := headerBits(header) + tokensBits(tokens, header.literals, header.distances)
+	fixedBits := tokensBits(tokens, d.literals, d.distances)
+	// LEN and NLEN, and up to 7 bits of padding for each stored block
+	storedBits := 8*len(raw) + (32+7)*(len(raw)/maxStoredBlock+1)
+	if storedBits < dynamicBits && storedBits < fixedBits {
+		return 0b00, storedBits, header
+	}
+	if dynamicBits < fixedBits {
+		return 0b10, dynamicBits, header
+	}
+	return 0b01, fixedBits, header
+}
+
+func (d *deflater) writeTokenBlock(tokens []token, raw []byte, final bool) {
+	blockType, _, header := d.blockType(tokens, raw)
+	if blockType == 0b00 {
+		writeStoredBlocks(d.writer, raw, final)
+		return
+	}
+	writeFinalBit(d.writer, final)
+	writeBitsInv(d.writer, uint64(blockType), 2)
+	if blockType == 0b10 {
+		writeDynamicHeader(d.writer, header)
+		writeTokens(d.writer, tokens, header.literals, header.distances)
+	} else {
+		writeTokens(d.writer, tokens, d.literals, d.distances)
+	}
+}
+
+func writeFinalBit(writer *bitWriter, final bool) {
+	var finalBit uint64
+	if final {
+		finalBit = 1
+	}
+	writeBitsInv(writer, finalBit, 1)
+}
+
+// writeStoredBlocks writes raw as stored blocks, the reverse of readStoredBlockHeader: after the 3 bits of the
+// block header and the padding to the next byte, LEN and NLEN, followed by the raw bytes
+func writeStoredBlocks(writer *bitWriter, raw []byte, final bool) {
+	for {
+		chunk := raw
+		if len(chunk) > maxStoredBlock {
+			chunk = chunk[:maxStoredBlock]
+		}
+		raw = raw[len(chunk):]
+		writeFinalBit(writer, final && len(raw) == 0)
+		writeBitsInv(writer, 0b00, 2)
+		alignWriter(writer)
+		length := uint64(len(chunk))
+		writeBitsInv(writer, length|(^length&0xFFFF)<<16, 32)
+		writer.Write(chunk) // errors are kept in writer.err
+		if len(raw) == 0 {
+			return
+		}
+	}
+}
+
+// writeTokens writes the codes of the tokens, followed by the end of block code
+func writeTokens(writer *bitWriter, tokens []token, literals, distances *huffmanEncoder) {
+	for _, t := range tokens {
+		if t.length == 0 {
+			encodeSymbol(writer, literals, int(t.literal)+1) // the literals tree is built with 1-indexing
+			continue
+		}
+		code, extraBits, extra := lengthSymbol(int(t.length))
+		encodeSymbol(writer, literals, code+1)
+		writeBitsInv(writer, uint64(extra), uint(extraBits))
+		code, extraBits, extra = distanceSymbol(int(t.dist))
+		encodeSymbol(writer, distances, code)
+		writeBitsInv(writer, uint64(extra), uint(extraBits))
+	}
+	encodeSymbol(writer, literals, endOfBlock+1)
+}
@@ -0,0 +1,68 @@
+package gzip
+
+/*
+Combining CRC-32s (from zlib's crc32_combine):
+
+The CRC-32 of A followed by B can be computed from crc(A), crc(B) and the length of B, without reading the data
+again. A CRC is the remainder of a polynomial division, which is linear: crc(A||B) is crc(A) "shifted" by the
+len(B) bytes of B, xor crc(B). Shifting a CRC by n zero bits is multiplying it by a 32x32 matrix over GF(2), and
+the matrix for 2n bits is the square of the matrix for n bits. So the shift by len(B) bytes is done by squaring the
+matrix over and over, applying the squares matching the bits of len(B).
+*/
+
+// gf2MatrixTimes multiplies the matrix (one uint32 column per bit) with the vector
+func gf2MatrixTimes(mat []uint32, vec uint32) uint32 {
+	var sum uint32
+	for i := 0; vec != 0; i, vec = i+1, vec>>1 {
+		if vec&1 != 0 {
+			sum ^= mat[i]
+		}
+	}
+	return sum
+}
+
+func gf2MatrixSquare(square, mat []uint32) {
+	for n := range mat {
+		square[n] = gf2MatrixTimes(mat, mat[n])
+	}
+}
+
+// crc32Combine returns the CRC-32 (IEEE) of A followed by B, given crc1 = crc(A), crc2 = crc(B) and len2 = len(B)
+func crc32Combine(crc1, crc2 uint32, len2 int64) uint32 {
+	if len2 <= 0 {
+		return crc1 ^ crc2
+	}
+	even := make([]uint32, 32) // operator for 2^n zero bits, n even
+	odd := make([]uint32, 32)  // operator for 2^n zero bits, n odd
+
+	// the operator for one zero bit
+	odd[0] = 0xedb88320 // the (reversed) polynomial of CRC-32
+	row := uint32(1)
+	for n := 1; n < 32; n++ {
+		odd[n] = row
+		row <<= 1
+	}
+	gf2MatrixSquare(even, odd) // 2 zero bits
+	gf2MatrixSquare(odd, even) // 4 zero bits
+
+	// the first square gives the operator for one zero byte (8 zero bits)
+	for {
+		gf2MatrixSquare(even, odd)
+		if len2&1 != 0 {
+			crc1 = gf2MatrixTimes(even, crc1)
+		}
+		len2 >>= 1
+		if len2 == 0 {
+			break
+		}
+		gf2MatrixSquare(odd, even)
+		if len2&1 != 0 {
+			crc1 = gf2MatrixTimes(odd, crc1)
+		}
+		len2 >>= 1
+		if len2 == 0 {
+			break
+		}
+	}
+	return crc1 ^ crc2
+}
@@ -0,0 +1,19 @@
+package gzip
+
+import (
+	"hash/crc32"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCrc32Combine(t *testing.T) {
+	data := make([]byte, 100000)
+	rand.New(rand.NewSource(1)).Read(data)
+	for _, split := range []int{0, 1, 7, 4096, 65536, 99999, 100000} {
+		a, b := data[:split], data[split:]
+		combined := crc32Combine(crc32.ChecksumIEEE(a), crc32.ChecksumIEEE(b), int64(len(b)))
+		assert.Equal(t, crc32.ChecksumIEEE(data), combined, "split at %d", split)
+	}
+}
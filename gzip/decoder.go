@@ -0,0 +1,57 @@
+package gzip
+
+import (
+	"io"
+)
+
+// Decoder holds the options of the decoder, and the statistics of the data it inflates. The readers it creates all
+// share them, so a Decoder must not be used by several goroutines at once: give each goroutine its own Decoder
+// (the NewReader functions of the package use a new one every time).
+type Decoder struct {
+	// Tracer receives the structure of the stream as it is decoded (see TextTracer), the decoder is silent without
+	// one
+	Tracer Tracer
+	// BitByBit decodes the huffman codes by walking the trees one bit at a time, instead of using the lookup tables.
+	// It's slower, but easier to follow in a debugger.
+	BitByBit bool
+
+	// Statistics of the inflated data, accumulated across all the readers of the Decoder
+	LiteralCount, BackPointerCount, TotalBytes int
+}
+
+// NewReader is like the package's NewReader, with the options and statistics of d
+func (d *Decoder) NewReader(r io.Reader) (*Reader, error) {
+	z := newReader(r, d)
+	if err := z.readMember(); err != nil {
+		return nil, err
+	}
+	return z, nil
+}
+
+// NewZlibReader is like the package's NewZlibReader, with the options and statistics of d
+func (d *Decoder) NewZlibReader(r io.Reader) (*ZlibReader, error) {
+	return d.NewZlibReaderDict(r, nil)
+}
+
+// NewZlibReaderDict is like the package's NewZlibReaderDict, with the options and statistics of d
+func (d *Decoder) NewZlibReaderDict(r io.Reader, dict []byte) (*ZlibReader, error) {
+	return newZlibReader(r, dict, d)
+}
+
+// NewRawReader is like the package's NewRawReader, with the options and statistics of d
+func (d *Decoder) NewRawReader(r io.Reader) *RawReader {
+	return d.NewRawReaderDict(r, nil)
+}
+
+// NewRawReaderDict is like the package's NewRawReaderDict, with the options and statistics of d
+func (d *Decoder) NewRawReaderDict(r io.Reader, dict []byte) *RawReader {
+	return newRawReader(r, dict, d)
+}
+
+// trace sends an event to the Tracer, if there is one. The events sent for every byte (literals and back-pointers)
+// check for the Tracer first instead, so no event is built for nothing.
+func (d *Decoder) trace(event TraceEvent) {
+	if d.Tracer != nil {
+		d.Tracer.Trace(event)
+	}
+}
@@ -0,0 +1,65 @@
+package gzip
+
+import (
+	"bytes"
+	"io"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecoderStatistics(t *testing.T) {
+	decoder := &Decoder{}
+	reader, err := decoder.NewReader(bytes.NewReader(compressForTest(t, []byte("hello hello hello world"))))
+	assert.NoError(t, err)
+	out, err := io.ReadAll(reader)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello hello hello world", string(out))
+	assert.Equal(t, len(out), decoder.TotalBytes)
+	assert.Equal(t, 1, decoder.BackPointerCount)
+	assert.Equal(t, len(out)-12, decoder.LiteralCount)
+
+	// the statistics add up across the readers of the decoder
+	var compressed bytes.Buffer
+	writer := newBitWriter(&compressed)
+	deflater, err := newDeflater(writer, DefaultCompression, DefaultStrategy)
+	assert.NoError(t, err)
+	assert.NoError(t, deflater.write(out))
+	assert.NoError(t, deflater.close())
+	_, err = io.ReadAll(decoder.NewRawReader(&compressed))
+	assert.NoError(t, err)
+	assert.Equal(t, 2*len(out), decoder.TotalBytes)
+}
+
+// each goroutine has its own Decoder, so they can inflate at the same time (run with -race)
+func TestDecoderConcurrent(t *testing.T) {
+	inputs := testInputs()
+	var wg sync.WaitGroup
+	for name, data := range inputs {
+		compressed := compressForTest(t, data)
+		for i := 0; i < 4; i++ {
+			wg.Add(1)
+			go func(i int, name string, data, compressed []byte) {
+				defer wg.Done()
+				var printed bytes.Buffer
+				decoder := &Decoder{Tracer: &TextTracer{Output: &printed, Inline: true}, BitByBit: i%2 == 0}
+				reader, err := decoder.NewReader(bytes.NewReader(compressed))
+				if !assert.NoError(t, err) {
+					return
+				}
+				out, err := io.ReadAll(reader)
+				assert.NoError(t, err)
+				assert.True(t, bytes.Equal(data, out), name)
+				assert.True(t, bytes.Equal(data, printed.Bytes()), name)
+				assert.Equal(t, len(data), decoder.TotalBytes, name)
+
+				// the package functions use a Decoder of their own
+				out, err = readGzipFile(bytes.NewReader(compressed))
+				assert.NoError(t, err)
+				assert.True(t, bytes.Equal(data, out), name)
+			}(i, name, data, compressed)
+		}
+	}
+	wg.Wait()
+}
@@ -0,0 +1,386 @@
+package gzip
+
+import (
+	"fmt"
+	"io"
+)
+
+func readFixedHuffmanTree(stream *bitstream, bitByBit bool) (literals *huffmanDecoder, distances *huffmanDecoder, err error) {
+	if literals, err = newHuffmanDecoder(fixedLiteralRanges, bitByBit); err != nil {
+		return nil, nil, err
+	}
+	if distances, err = newHuffmanDecoder(fixedDistanceRanges, bitByBit); err != nil {
+		return nil, nil, err
+	}
+	return literals, distances, nil
+}
+
+// codeCounts are the numbers of codes declared by the header of a dynamic block
+type codeCounts struct {
+	literals, distances, codeLengths int
+}
+
+func readDynamicHuffmanTree(stream *bitstream, bitByBit bool) (literals *huffmanDecoder, distances *huffmanDecoder, counts codeCounts, err error) {
+	/*
+		format is:
+		- header (hlit|hdist|hclen)
+		- (hclen+4) * 3 code lengths
+		- followed by 257 + hdist + hlit literals
+
+		hdist and hlit should help to define the distance and length codes
+	*/
+
+	hlit, err := readBitsInv(stream, 5)
+	if err != nil {
+		return nil, nil, counts, err
+	}
+	hdist, err := readBitsInv(stream, 5)
+	if err != nil {
+		return nil, nil, counts, err
+	}
+
+	// there are (hclen + 4) number of codes
+	hclen, err := readBitsInv(stream, 4)
+	if err != nil {
+		return nil, nil, counts, err
+	}
+
+	counts = codeCounts{literals: hlit + 257, distances: hdist + 1, codeLengths: hclen + 4}
+
+	// read codes
+	codeBitLengths, err := readCodesBitLengths(stream, hclen)
+	if err != nil {
+		return nil, nil, counts, err
+	}
+	codeHuffmanDecoder, err := newHuffmanDecoder(runLengthEncoding(codeBitLengths), bitByBit)
+	if err != nil {
+		return nil, nil, counts, decodeError(stream, err)
+	}
+
+	// read alphabet
+	alphabetsBitLengths, err := readAlphabetsBitLengths(stream, 258+hlit+hdist, codeHuffmanDecoder)
+	if err != nil {
+		return nil, nil, counts, err
+	}
+
+	// split alphabets into literals and distances
+	literalsBitLengths := alphabetsBitLengths[:hlit+257]
+	distancesBitLengths := alphabetsBitLengths[hlit+257:]
+	literalsRLE := runLengthEncoding(append([]int{0}, literalsBitLengths...)) // Seems to be using 1-indexing
+	distancesRLE := runLengthEncoding(distancesBitLengths)
+	if literals, err = newHuffmanDecoder(literalsRLE, bitByBit); err != nil {
+		return nil, nil, counts, decodeError(stream, err)
+	}
+	if distances, err = newHuffmanDecoder(distancesRLE, bitByBit); err != nil {
+		return nil, nil, counts, decodeError(stream, err)
+	}
+	return literals, distances, counts, nil
+}
+
+func readCodesBitLengths(stream *bitstream, hclen int) ([]int, error) {
+	// The specification refers to the repetition codes as the values 16, 17, and 18,
+	//	but these numbers don't have any real physical meaning
+	//	16 means "repeat the previous character n times",
+	//	and 17 & 18 mean "insert n 0's".
+	//	The number n follows the repeat codes and is encoded
+	//	(without compression) in 2, 3 or 7 bits, respectively
+
+	codeBitLengths := make([]int, 19) // max hclen (0b1111) + 4
+	for i := 0; i < (hclen + 4); i++ {
+		var err error
+		if codeBitLengths[codeLengthOrder[i]], err = readBitsInv(stream, 3); err != nil {
+			return nil, err
+		}
+	}
+
+	return codeBitLengths, nil
+}
+
+func readAlphabetsBitLengths(stream *bitstream, alphabetCount int, codeLengths *huffmanDecoder) ([]int, error) {
+	alphabetBitLengths := make([]int, alphabetCount)
+
+	i := 0
+	for i < alphabetCount {
+		code, err := decodeSymbol(stream, codeLengths)
+		if err != nil {
+			return nil, err
+		}
+		// 0-15: literal (4 bits)
+		// 16: repeat the previous character n+3 times (2 extra bits specified)
+		// 17: insert n 0's (3 bit specified), max value is 10
+		// 18: insert n 0's (7 bit specifier), add 11 (because it's the max of code 17)
+		if code == 16 || code == 17 || code == 18 {
+			var repeatLength, repeatValue int
+			if code == 16 {
+				if i == 0 {
+					return nil, decodeError(stream, fmt.Errorf("%w: nothing to repeat", ErrInvalidHuffmanTree))
+				}
+				repeatLength, err = readBitsInv(stream, 2)
+				repeatLength += 3
+				repeatValue = alphabetBitLengths[i-1]
+			} else if code == 17 {
+				repeatLength, err = readBitsInv(stream, 3)
+				repeatLength += 3
+			} else {
+				repeatLength, err = readBitsInv(stream, 7)
+				repeatLength += 11
+			}
+			if err != nil {
+				return nil, err
+			}
+			if i+repeatLength > alphabetCount {
+				return nil, decodeError(stream, fmt.Errorf("%w: repeating past the %d code lengths", ErrInvalidHuffmanTree, alphabetCount))
+			}
+			for j := 0; j < repeatLength; j++ {
+				alphabetBitLengths[i] = repeatValue
+				i++
+			}
+		} else {
+			alphabetBitLengths[i] = code
+			i++
+		}
+	}
+	return alphabetBitLengths, nil
+}
+
+const (
+	stateBlockHeader = iota // the next bits are the header of a block
+	stateStored             // copying the raw bytes of a stored block
+	stateHuffman            // decoding the codes of a huffman block
+	stateDone               // the final block has been inflated
+)
+
+// inflater decodes a deflate stream block by block. Only the last 32 KiB of output are kept (in window), the
+// inflated bytes are handed out through Read every time the window fills up. So the memory used doesn't depend on
+// the size of the stream.
+type inflater struct {
+	stream  *bitstream
+	decoder *Decoder // options, tracer, and where the statistics go
+	window  slidingWindow
+	state   int
+	final   bool  // the current block is the last one
+	pos     int64 // number of bytes inflated so far
+	maxDist int   // back-pointers can't reach further than this (a zlib stream may declare a smaller window)
+
+	storedRemaining      int // bytes of the stored block that haven't been copied yet
+	literals, distances  *huffmanDecoder
+	copyLength, copyDist int // what's left of a back-pointer interrupted by a full window
+
+	toRead []byte // flushed from window but not read yet
+	err    error
+
+	blockEnd func(f *inflater) // called between two blocks, where the inflation could be resumed (see Index)
+}
+
+func newInflater(stream *bitstream, decoder *Decoder) *inflater {
+	if decoder == nil {
+		decoder = &Decoder{}
+	}
+	return &inflater{stream: stream, decoder: decoder, window: newSlidingWindow(), maxDist: windowSize}
+}
+
+// Read returns io.EOF once the final block has been inflated, the stream is then positioned right after its last
+// bit.
+func (f *inflater) Read(p []byte) (int, error) {
+	for len(f.toRead) == 0 {
+		if f.err != nil {
+			return 0, f.err
+		}
+		f.err = f.inflate()
+		f.toRead = f.window.flush()
+	}
+	n := copy(p, f.toRead)
+	f.toRead = f.toRead[n:]
+	return n, nil
+}
+
+// inflate decodes blocks until the window is full, or returns io.EOF after the final block
+func (f *inflater) inflate() error {
+	for f.window.available() > 0 {
+		var err error
+		switch f.state {
+		case stateBlockHeader:
+			err = f.readBlockHeader()
+		case stateStored:
+			err = f.inflateStoredBlock()
+		case stateHuffman:
+			err = f.inflateHuffmanCodes()
+		case stateDone:
+			return io.EOF
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *inflater) readBlockHeader() error {
+	block := BlockStartEvent{Offset: f.pos, CompressedBits: bitOffset(f.stream)}
+	lastBlock, err := nextBit(f.stream)
+	if err != nil {
+		return err
+	}
+	f.final = lastBlock == 1
+	blockFormat, err := readBitsInv(f.stream, 2)
+	if err != nil {
+		return err
+	}
+	block.Type, block.Final = blockFormat, f.final
+	switch blockFormat {
+	case StoredBlock:
+		err = f.readStoredBlockHeader()
+		block.StoredLength = f.storedRemaining
+	case FixedBlock:
+		f.literals, f.distances, err = readFixedHuffmanTree(f.stream, f.decoder.BitByBit)
+		f.state = stateHuffman
+	case DynamicBlock:
+		var counts codeCounts
+		f.literals, f.distances, counts, err = readDynamicHuffmanTree(f.stream, f.decoder.BitByBit)
+		block.LiteralCodes, block.DistanceCodes, block.CodeLengthCodes = counts.literals, counts.distances, counts.codeLengths
+		f.state = stateHuffman
+	default:
+		return decodeError(f.stream, fmt.Errorf("%w: %02b", ErrInvalidBlockType, blockFormat))
+	}
+	if err != nil {
+		return err
+	}
+	f.decoder.trace(block)
+	return nil
+}
+
+// endBlock moves on to the next block, if any
+func (f *inflater) endBlock() {
+	f.decoder.trace(BlockEndEvent{Offset: f.pos, Final: f.final})
+	if f.final {
+		f.state = stateDone
+	} else {
+		f.state = stateBlockHeader
+		if f.blockEnd != nil {
+			f.blockEnd(f)
+		}
+	}
+}
+
+// readStoredBlockHeader reads the header of an uncompressed (0b00) block.
+// The block starts at the next byte boundary with LEN and NLEN (the one's complement of LEN), both 2 bytes
+// little-endian, followed by LEN raw bytes.
+func (f *inflater) readStoredBlockHeader() error {
+	alignToByte(f.stream)
+	header, err := readBytes(f.stream, 4)
+	if err != nil {
+		return err
+	}
+	length := uint16(header[0]) | uint16(header[1])<<8
+	nlength := uint16(header[2]) | uint16(header[3])<<8
+	if length != ^nlength {
+		return decodeError(f.stream, fmt.Errorf("%w: LEN %d, NLEN %d", ErrInvalidStoredBlock, length, nlength))
+	}
+	f.storedRemaining = int(length)
+	f.state = stateStored
+	return nil
+}
+
+// inflateStoredBlock copies the raw bytes of a stored block into the window, as many as it has space for
+func (f *inflater) inflateStoredBlock() error {
+	raw := f.window.writeSlice()
+	if len(raw) > f.storedRemaining {
+		raw = raw[:f.storedRemaining]
+	}
+	if _, err := io.ReadFull(f.stream, raw); err != nil {
+		return decodeError(f.stream, err)
+	}
+	if f.decoder.Tracer != nil {
+		f.decoder.Tracer.Trace(StoredEvent{Offset: f.pos, Data: raw})
+	}
+	f.window.advance(len(raw))
+	f.storedRemaining -= len(raw)
+	f.pos += int64(len(raw))
+	f.decoder.TotalBytes += len(raw)
+	if f.storedRemaining == 0 {
+		f.endBlock()
+	}
+	return nil
+}
+
+// inflateHuffmanCodes decodes the codes of a huffman block into the window, until the end of the block or until
+// the window is full.
+func (f *inflater) inflateHuffmanCodes() error {
+	stream := f.stream
+	for f.window.available() > 0 {
+		if f.copyLength > 0 {
+			copied := f.window.writeCopy(f.copyDist, f.copyLength)
+			f.copyLength -= len(copied)
+			f.pos += int64(len(copied))
+			f.decoder.TotalBytes += len(copied)
+			continue
+		}
+
+		code, err := decodeSymbol(stream, f.literals)
+		if err != nil {
+			return err
+		}
+		code -= 1 // the literals tree is built with 1-indexing
+		if code >= 0 && code < 256 {
+			// literal code
+			f.decoder.LiteralCount += 1
+			f.decoder.TotalBytes += 1
+
+			if f.decoder.Tracer != nil {
+				f.decoder.Tracer.Trace(LiteralEvent{Offset: f.pos, Value: byte(code)})
+			}
+			f.window.writeByte(byte(code))
+			f.pos++
+		} else if code == 256 {
+			// stop code
+			f.endBlock()
+			return nil
+		} else if code > 256 && code <= 285 {
+			// This is a back-pointer
+			f.decoder.BackPointerCount += 1
+
+			// get length
+			var length int
+			if code < 265 {
+				length = code - 254
+			} else if code == 285 {
+				length = 258 // this seems to be for a short cut for the 284? not sure why don't we use 259 instead?
+			} else {
+				extraLength, err := readBitsInv(stream, (code-261)/4)
+				if err != nil {
+					return err
+				}
+				length = extraLengthAddend[code-265] + extraLength
+			}
+
+			dist, err := decodeSymbol(stream, f.distances)
+			if err != nil {
+				return err
+			}
+			if dist > 29 {
+				return decodeError(stream, fmt.Errorf("%w: distance code %d", ErrInvalidDistance, dist))
+			}
+			if dist > 3 {
+				extraDist, err := readBitsInv(stream, (dist-2)/2)
+				if err != nil {
+					return err
+				}
+				dist = extraDist + extraDistAddend[dist-4]
+			}
+			dist += 1 // the codes start from a distance of 1
+			if dist > f.window.historySize() {
+				return decodeError(stream, fmt.Errorf("%w: %d is before the start of the stream", ErrInvalidDistance, dist))
+			}
+			if dist > f.maxDist {
+				return decodeError(stream, fmt.Errorf("%w: %d is larger than the window size %d", ErrInvalidDistance, dist, f.maxDist))
+			}
+			if f.decoder.Tracer != nil {
+				f.decoder.Tracer.Trace(MatchEvent{Offset: f.pos, Length: length, Distance: dist})
+			}
+			f.copyLength, f.copyDist = length, dist
+		} else {
+			return decodeError(stream, fmt.Errorf("%w: literal/length code %d", ErrInvalidHuffmanCode, code))
+		}
+	}
+	return nil
+}
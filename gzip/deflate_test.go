@@ -0,0 +1,338 @@
+package gzip
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"os"
+	"testing"
+)
+
+func TestReadCodes(t *testing.T) {
+	hclen := 8 // there are twelve codes
+	/*
+		110	111	111	011	011	010	011	011	100	100	101	100
+		16	17	18	0 	8 	7 	9 	6 	10	5 	11	4
+		6	7	7	3	3	2	3	3	4	4	5	4
+	*/
+	stream := &bitstream{
+		source: bytes.NewReader([]byte{
+			0b11_111_110,
+			0b0_011_011_1,
+			0b011_011_01,
+			0b01_100_100,
+			0b100_1,
+		}),
+	}
+
+	expectedBitLengths := []int{
+		3, 0, 0, 0, 4, // 0-4
+		4, 3, 2, 3, 3, // 5-9
+		4, 5, 0, 0, 0, // 10-14
+		0, 6, 7, 7, // 15-18
+	}
+	codesBitLengths, err := readCodesBitLengths(stream, hclen)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedBitLengths, codesBitLengths)
+}
+
+func TestReadAlphabetsBitLengths(t *testing.T) {
+	codesHRanges := []rleRange{
+		{0, 3},
+		{3, 0},
+		{5, 4},
+		{6, 3},
+		{7, 2},
+		{9, 3},
+		{10, 4},
+		{11, 5},
+		{15, 0},
+		{16, 6},
+		{18, 7},
+	}
+	codesHuffmanTreeRoot, err := buildHuffmanTree(codesHRanges)
+	assert.NoError(t, err)
+	debugCodeTable := make([]string, 19)
+	traverseHuffmanTree(codesHuffmanTreeRoot, "", debugCodeTable)
+
+	source := bytes.NewReader(helperBitStringToBytes("1111110111001111111010100011011011001110"))
+	/*
+		1111110	 111
+		17	     repeat '0' 10
+
+		00
+		7 (literal)
+
+		1111111	0101000
+		18	    repeat '0' 21 times
+
+		1101
+		5 (literal)
+
+		101
+		9 (literal)
+
+		100
+		8 (literal)
+
+		1110
+		10 (literal)
+	*/
+	alphabetBitLengths := []int{
+		// there are 10 + 1 (7) + 21 + 4 (5, 9, 8, 10) = 36 alphabets
+		0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+		7, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+		0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+		0, 0, 5, 9, 8, 10,
+	}
+	alphabetsBitLengths, err := readAlphabetsBitLengths(&bitstream{source: source}, len(alphabetBitLengths),
+		&huffmanDecoder{root: codesHuffmanTreeRoot})
+	assert.NoError(t, err)
+	assert.Equal(t, alphabetBitLengths, alphabetsBitLengths)
+
+	// same thing, using the lookup tables instead of the tree
+	codesHuffmanTable, err := buildHuffmanTable(codesHRanges)
+	assert.NoError(t, err)
+	source.Reset(helperBitStringToBytes("1111110111001111111010100011011011001110"))
+	alphabetsBitLengths, err = readAlphabetsBitLengths(&bitstream{source: source}, len(alphabetBitLengths),
+		&huffmanDecoder{table: codesHuffmanTable})
+	assert.NoError(t, err)
+	assert.Equal(t, alphabetBitLengths, alphabetsBitLengths)
+}
+
+// inflateHuffmanBlock inflates a single huffman block, following the history inflated by the previous blocks
+func inflateHuffmanBlock(stream *bitstream, literals *huffmanDecoder, distances *huffmanDecoder, history []byte) ([]byte, error) {
+	f := newInflater(stream, nil)
+	f.window.advance(copy(f.window.writeSlice(), history))
+	f.window.flush()
+	f.literals, f.distances = literals, distances
+	f.state, f.final = stateHuffman, true
+	out, err := io.ReadAll(f)
+	return append(history, out...), err
+}
+
+func TestInflateHuffmanCodesNoBackPointer(t *testing.T) {
+	// These are inefficient huffman trees. This is used to make it easier to create the test cases
+	literals, err := newHuffmanDecoder([]rleRange{
+		{0, 0},
+		{286, 16},
+	}, false)
+	assert.NoError(t, err)
+	distances, err := newHuffmanDecoder([]rleRange{
+		{30, 8},
+	}, false)
+	assert.NoError(t, err)
+
+	stream := &bitstream{
+		source: bytes.NewReader([]byte{
+			0x00, 0x00, // 0x00
+			0x00, 0x80, // 0x01
+			0x00, 0x40, // 0x02
+			0x00, 0x20, // 0x04
+			0x80, 0x00, // stop code
+		}),
+	}
+	outBytes, err := inflateHuffmanBlock(stream, literals, distances, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0x00, 0x01, 0x02, 0x04}, outBytes)
+}
+
+func TestInflateHuffmanCodesWithLiteralBackPointer(t *testing.T) {
+	// These are inefficient huffman trees. This is used to make it easier to create the test cases
+	literals, err := newHuffmanDecoder([]rleRange{
+		{0, 0},
+		{286, 16},
+	}, false)
+	assert.NoError(t, err)
+	distances, err := newHuffmanDecoder([]rleRange{
+		{30, 8},
+	}, false)
+	assert.NoError(t, err)
+
+	stream := &bitstream{
+		source: bytes.NewReader([]byte{
+			0x00, 0x00, // 0x00
+			0x00, 0x80, // 0x01
+			0x00, 0x40, // 0x02
+			0x00, 0x20, // 0x04
+			0x80, 0x40, // Code 258, back-pointer length of 4
+			0x40,       // distance code 2,
+			0x00, 0xC0, // 0x03
+			0x80, 0x00, // stop code
+		}),
+	}
+	outBytes, err := inflateHuffmanBlock(stream, literals, distances, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0x00, 0x01, 0x02, 0x04, 0x01, 0x02, 0x04, 0x01, 0x03}, outBytes)
+}
+
+func TestInflateHuffmanCodesBackPointerToPreviousBlock(t *testing.T) {
+	literals, err := newHuffmanDecoder([]rleRange{
+		{0, 0},
+		{286, 16},
+	}, false)
+	assert.NoError(t, err)
+	distances, err := newHuffmanDecoder([]rleRange{
+		{30, 8},
+	}, false)
+	assert.NoError(t, err)
+
+	stream := &bitstream{
+		source: bytes.NewReader([]byte{
+			0x80, 0x80, // Code 257, back-pointer length of 3
+			0x40,       // distance code 2,
+			0x80, 0x00, // stop code
+		}),
+	}
+	// the back-pointer reaches into the bytes decoded by the previous block
+	outBytes, err := inflateHuffmanBlock(stream, literals, distances, []byte("abc"))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("abcabc"), outBytes)
+}
+
+func TestInflateHuffmanCodesErrors(t *testing.T) {
+	literals, err := newHuffmanDecoder([]rleRange{
+		{0, 0},
+		{286, 16},
+	}, false)
+	assert.NoError(t, err)
+	distances, err := newHuffmanDecoder([]rleRange{
+		{30, 8},
+	}, false)
+	assert.NoError(t, err)
+
+	stream := &bitstream{
+		source: bytes.NewReader([]byte{
+			0x00, 0x00, // 0x00
+			0x80, 0x80, // Code 257, back-pointer length of 3
+			0x40, // distance code 2, but only 1 byte has been decoded so far
+		}),
+	}
+	outBytes, err := inflateHuffmanBlock(stream, literals, distances, nil)
+	assert.ErrorIs(t, err, ErrInvalidDistance)
+	assert.Equal(t, []byte{0x00}, outBytes)
+
+	stream = &bitstream{
+		source: bytes.NewReader([]byte{
+			0xFF, 0xFF, // all codes are 16-bit long starting with 0000000, so 1111... is not a valid code
+		}),
+	}
+	_, err = inflateHuffmanBlock(stream, literals, distances, nil)
+	assert.ErrorIs(t, err, ErrInvalidHuffmanCode)
+	var decodeErr *DecodeError
+	if assert.ErrorAs(t, err, &decodeErr) {
+		// the position of the invalid code
+		assert.Equal(t, int64(0), decodeErr.Offset)
+		assert.Equal(t, 0, decodeErr.Bit)
+	}
+}
+
+func TestInflateStoredBlock(t *testing.T) {
+	source := bytes.NewReader([]byte{
+		0b0000_0_00_1, // final block, block type 0b00, the rest of the byte is padding
+		0x05, 0x00,    // LEN
+		0xFA, 0xFF, // NLEN
+		'h', 'e', 'l', 'l', 'o',
+	})
+	out, err := io.ReadAll(newInflater(&bitstream{source: source}, nil))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("hello"), out)
+}
+
+func TestInflateStoredBlockInvalidLength(t *testing.T) {
+	stream := &bitstream{
+		source: bytes.NewReader([]byte{
+			0x05, 0x00, // LEN
+			0xFA, 0xFE, // NLEN, not the complement of LEN
+			'h', 'e', 'l', 'l', 'o',
+		}),
+	}
+	err := newInflater(stream, nil).readStoredBlockHeader()
+	assert.ErrorIs(t, err, ErrInvalidStoredBlock)
+}
+
+func TestInflateStoredBlocksAcrossBlocks(t *testing.T) {
+	source := bytes.NewReader([]byte{
+		0b0000_0_00_0, // non-final block, block type 0b00
+		0x02, 0x00, 0xFD, 0xFF,
+		'a', 'b',
+		0b0000_0_00_0, // empty stored block, as emitted by a sync flush
+		0x00, 0x00, 0xFF, 0xFF,
+		0b0000_0_00_1, // final block
+		0x01, 0x00, 0xFE, 0xFF,
+		'c',
+	})
+	out, err := io.ReadAll(newInflater(&bitstream{source: source}, nil))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("abc"), out)
+}
+
+func TestSmokeAttachment(t *testing.T) {
+	file, err := os.Open("../attachment/genesis.txt.gz")
+	if err != nil {
+		panic(err)
+	}
+	_, err = readGzipFile(file)
+	assert.NoError(t, err)
+
+	file, err = os.Open("../attachment/gunzip.c.gz")
+	if err != nil {
+		panic(err)
+	}
+	_, err = readGzipFile(file)
+	assert.NoError(t, err)
+}
+
+func TestStoredAttachment(t *testing.T) {
+	expected, err := os.ReadFile("../attachment/let_it_be.txt")
+	if err != nil {
+		panic(err)
+	}
+	file, err := os.Open("../attachment/let_it_be_stored.txt.gz")
+	if err != nil {
+		panic(err)
+	}
+	out, err := readGzipFile(file)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, out)
+}
+
+func TestInflateLargerThanWindow(t *testing.T) {
+	// feynman.txt is larger than 32 KiB, so the window wraps around while inflating it
+	expected, err := os.ReadFile("../attachment/feynman.txt")
+	if err != nil {
+		panic(err)
+	}
+	assert.Greater(t, len(expected), windowSize)
+	file, err := os.Open("../attachment/feynman.txt.gz")
+	if err != nil {
+		panic(err)
+	}
+	out, err := readGzipFile(file)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, out)
+}
+
+func TestInflateFixedHuffmanBlock(t *testing.T) {
+	// zlib.compress(b"hello hello hello world") with the zlib header and trailer stripped
+	source := bytes.NewReader([]byte{0xcb, 0x48, 0xcd, 0xc9, 0xc9, 0x57, 0xc8, 0x40, 0x22, 0xcb, 0xf3, 0x8b, 0x72, 0x52, 0x00})
+	out, err := io.ReadAll(newInflater(&bitstream{source: source}, nil))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("hello hello hello world"), out)
+}
+
+func TestInflateTreeAndTableDecoding(t *testing.T) {
+	compressed, err := os.ReadFile("../attachment/gunzip.c.gz")
+	if err != nil {
+		panic(err)
+	}
+	tableOut, err := readGzipFile(bytes.NewReader(compressed))
+	assert.NoError(t, err)
+
+	decoder := &Decoder{BitByBit: true}
+	reader, err := decoder.NewReader(bytes.NewReader(compressed))
+	assert.NoError(t, err)
+	treeOut, err := io.ReadAll(reader)
+	assert.NoError(t, err)
+	assert.Equal(t, tableOut, treeOut)
+}
@@ -0,0 +1,195 @@
+package gzip
+
+const (
+	endOfBlock        = 256
+	literalCodes      = 286 // 0-255 literals, 256 end of block, 257-285 lengths
+	distanceCodes     = 30
+	maxLiteralBits    = 15 // limit of the literals and distances code lengths
+	maxCodeLengthBits = 7  // limit of the code lengths alphabet (written with 3 bits)
+)
+
+// codeLengthToken is a symbol of the code lengths alphabet: a length (0-15), or a repeat code (16-18) followed by
+// its extra bits
+type codeLengthToken struct {
+	symbol int
+	extra  int
+}
+
+// codeLengthExtraBits gives the number of extra bits of each repeat code
+var codeLengthExtraBits = map[int]int{16: 2, 17: 3, 18: 7}
+
+// dynamicHeader is what the encoder needs to write the header of a dynamic huffman block (block type 0b10), the
+// reverse of readDynamicHuffmanTree
+type dynamicHeader struct {
+	literalBitLengths    []int // hlit + 257 of them
+	distanceBitLengths   []int // hdist + 1 of them
+	codeLengths          []codeLengthToken
+	codeLengthBitLengths []int // indexed by symbol, not in codeLengthOrder
+	hclen                int
+
+	literals, distances, codeLengthCodes *huffmanEncoder
+}
+
+// tokenFrequencies counts how often each literal/length and distance code is used by the tokens
+func tokenFrequencies(tokens []token) (literals []int, distances []int) {
+	literals = make([]int, literalCodes)
+	distances = make([]int, distanceCodes)
+	for _, t := range tokens {
+		if t.length == 0 {
+			literals[t.literal]++
+			continue
+		}
+		code, _, _ := lengthSymbol(int(t.length))
+		literals[code]++
+		code, _, _ = distanceSymbol(int(t.dist))
+		distances[code]++
+	}
+	literals[endOfBlock]++
+	return literals, distances
+}
+
+// buildDynamicHeader chooses the codes of a block given the frequencies of its symbols
+func buildDynamicHeader(literalFreqs, distanceFreqs []int) *dynamicHeader {
+	header := &dynamicHeader{
+		literalBitLengths:  huffmanBitLengths(withTwoSymbols(literalFreqs), maxLiteralBits),
+		distanceBitLengths: huffmanBitLengths(withTwoSymbols(distanceFreqs), maxLiteralBits),
+	}
+	// the unused codes at the end don't have to be written (but there are at least 257 and 1 of them)
+	header.literalBitLengths = trimZeros(header.literalBitLengths, 257)
+	header.distanceBitLengths = trimZeros(header.distanceBitLengths, 1)
+
+	// the literals and distances code lengths are written as one sequence, so the repeats can go across both
+	header.codeLengths = encodeCodeLengths(append(append([]int{}, header.literalBitLengths...), header.distanceBitLengths...))
+	codeLengthFreqs := make([]int, len(codeLengthOrder))
+	for _, t := range header.codeLengths {
+		codeLengthFreqs[t.symbol]++
+	}
+	header.codeLengthBitLengths = huffmanBitLengths(codeLengthFreqs, maxCodeLengthBits)
+	header.hclen = len(codeLengthOrder)
+	for header.hclen > 4 && header.codeLengthBitLengths[codeLengthOrder[header.hclen-1]] == 0 {
+		header.hclen--
+	}
+
+	header.literals = mustHuffmanEncoder(append([]int{0}, header.literalBitLengths...)) // 1-indexing
+	header.distances = mustHuffmanEncoder(header.distanceBitLengths)
+	header.codeLengthCodes = mustHuffmanEncoder(header.codeLengthBitLengths)
+	return header
+}
+
+// withTwoSymbols makes sure at least two symbols are used, like zlib does: some decoders reject a code with a
+// single symbol (or none, if there isn't any back-pointer).
+func withTwoSymbols(freqs []int) []int {
+	used := 0
+	for _, freq := range freqs {
+		if freq > 0 {
+			used++
+		}
+	}
+	if used >= 2 {
+		return freqs
+	}
+	freqs = append([]int{}, freqs...)
+	for i := 0; used < 2; i++ {
+		if freqs[i] == 0 {
+			freqs[i] = 1
+			used++
+		}
+	}
+	return freqs
+}
+
+func trimZeros(lengths []int, min int) []int {
+	for len(lengths) > min && lengths[len(lengths)-1] == 0 {
+		lengths = lengths[:len(lengths)-1]
+	}
+	return lengths
+}
+
+func mustHuffmanEncoder(bitLengths []int) *huffmanEncoder {
+	encoder, err := newHuffmanEncoder(runLengthEncoding(bitLengths))
+	if err != nil {
+		panic(err) // the lengths built by huffmanBitLengths are always valid
+	}
+	return encoder
+}
+
+// encodeCodeLengths turns the runs of identical bit lengths into repeat codes, the reverse of
+// readAlphabetsBitLengths:
+// - 16 repeats the previous length 3-6 times
+// - 17 inserts 3-10 zeros
+// - 18 inserts 11-138 zeros
+func encodeCodeLengths(bitLengths []int) (codeLengths []codeLengthToken) {
+	previousEnd := -1
+	for _, hRange := range runLengthEncoding(bitLengths) {
+		count := hRange.end - previousEnd
+		previousEnd = hRange.end
+		if hRange.bitLength == 0 {
+			for count >= 11 {
+				repeat := count
+				if repeat > 138 {
+					repeat = 138
+				}
+				codeLengths = append(codeLengths, codeLengthToken{18, repeat - 11})
+				count -= repeat
+			}
+			if count >= 3 {
+				codeLengths = append(codeLengths, codeLengthToken{17, count - 3})
+				count = 0
+			}
+		} else {
+			// the length has to be written once before it can be repeated
+			codeLengths = append(codeLengths, codeLengthToken{hRange.bitLength, 0})
+			count--
+			for count >= 3 {
+				repeat := count
+				if repeat > 6 {
+					repeat = 6
+				}
+				codeLengths = append(codeLengths, codeLengthToken{16, repeat - 3})
+				count -= repeat
+			}
+		}
+		for ; count > 0; count-- {
+			codeLengths = append(codeLengths, codeLengthToken{hRange.bitLength, 0})
+		}
+	}
+	return codeLengths
+}
+
+// headerBits returns the size of the header, in bits (not counting the 3 bits of the block header)
+func headerBits(header *dynamicHeader) int {
+	bits := 5 + 5 + 4 + 3*header.hclen
+	for _, t := range header.codeLengths {
+		bits += header.codeLengthCodes.codes[t.symbol].len + codeLengthExtraBits[t.symbol]
+	}
+	return bits
+}
+
+func writeDynamicHeader(writer *bitWriter, header *dynamicHeader) {
+	writeBitsInv(writer, uint64(len(header.literalBitLengths)-257), 5)
+	writeBitsInv(writer, uint64(len(header.distanceBitLengths)-1), 5)
+	writeBitsInv(writer, uint64(header.hclen-4), 4)
+	for _, symbol := range codeLengthOrder[:header.hclen] {
+		writeBitsInv(writer, uint64(header.codeLengthBitLengths[symbol]), 3)
+	}
+	for _, t := range header.codeLengths {
+		encodeSymbol(writer, header.codeLengthCodes, t.symbol)
+		writeBitsInv(writer, uint64(t.extra), uint(codeLengthExtraBits[t.symbol]))
+	}
+}
+
+// tokensBits returns the size of the codes of the tokens (including the end of block code), in bits
+func tokensBits(tokens []token, literals, distances *huffmanEncoder) int {
+	bits := literals.codes[endOfBlock+1].len
+	for _, t := range tokens {
+		if t.length == 0 {
+			bits += literals.codes[int(t.literal)+1].len
+			continue
+		}
+		code, extraBits, _ := lengthSymbol(int(t.length))
+		bits += literals.codes[code+1].len + extraBits
+		code, extraBits, _ = distanceSymbol(int(t.dist))
+		bits += distances.codes[code].len + extraBits
+	}
+	return bits
+}
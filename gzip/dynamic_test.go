@@ -0,0 +1,50 @@
+package gzip
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodeCodeLengths(t *testing.T) {
+	bitLengths := []int{
+		3, 3, 3, 3, 3, 3, 3, 3, // 3 then 16 (6 times) then 3
+		0, 0, // too short to repeat
+		4,
+		0, 0, 0, 0, 0, // 17
+	}
+	bitLengths = append(bitLengths, make([]int, 150)...) // 155 zeros: 18 (138 times) then 18 (17 times)
+	codeLengths := encodeCodeLengths(bitLengths)
+	assert.Equal(t, []codeLengthToken{
+		{3, 0}, {16, 3}, {3, 0},
+		{0, 0}, {0, 0},
+		{4, 0},
+		{18, 138 - 11}, {18, 17 - 11},
+	}, codeLengths)
+}
+
+func TestDynamicHeaderRoundTrip(t *testing.T) {
+	tokens := []token{
+		literalToken('a'), literalToken('b'), literalToken('a'),
+		matchToken(3, 2), matchToken(258, 1), matchToken(10, 3),
+		literalToken('z'),
+	}
+	header := buildDynamicHeader(tokenFrequencies(tokens))
+
+	var out bytes.Buffer
+	writer := newBitWriter(&out)
+	writeDynamicHeader(writer, header)
+	writeTokens(writer, tokens, header.literals, header.distances)
+	alignWriter(writer)
+	assert.NoError(t, flushWriter(writer))
+	assert.Equal(t, (headerBits(header)+tokensBits(tokens, header.literals, header.distances)+7)/8, out.Len())
+
+	stream := &bitstream{source: &out}
+	literals, distances, counts, err := readDynamicHuffmanTree(stream, false)
+	assert.Equal(t, codeCounts{len(header.literalBitLengths), len(header.distanceBitLengths), header.hclen}, counts)
+	assert.NoError(t, err)
+	history, err := inflateHuffmanBlock(stream, literals, distances, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "ababab"+string(bytes.Repeat([]byte{'b'}, 258+10))+"z", string(history))
+}
@@ -0,0 +1,56 @@
+package gzip
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+var (
+	ErrBadMagic           = errors.New("gzip: invalid magic bytes, not a gzip file")
+	ErrUnsupportedMethod  = errors.New("gzip: unsupported compression method")
+	ErrInvalidBlockType   = errors.New("deflate: invalid block type")
+	ErrInvalidStoredBlock = errors.New("deflate: stored block length doesn't match its complement")
+	ErrInvalidHuffmanTree = errors.New("deflate: invalid huffman code lengths")
+	ErrInvalidHuffmanCode = errors.New("deflate: invalid huffman code")
+	ErrInvalidDistance    = errors.New("deflate: invalid back-pointer distance")
+	ErrUnexpectedEOF      = io.ErrUnexpectedEOF
+	ErrChecksum           = errors.New("checksum of the inflated data doesn't match the trailer")
+	ErrSize               = errors.New("gzip: size of the inflated data doesn't match the trailer")
+	ErrZlibHeader         = errors.New("zlib: invalid header")
+	ErrDictionary         = errors.New("zlib: missing or wrong preset dictionary")
+	ErrInvalidIndex       = errors.New("gzip: invalid index")
+	ErrInvalidExtra       = errors.New("gzip: invalid extra field")
+	ErrNotBGZF            = errors.New("bgzf: missing BC subfield, not a BGZF block")
+	ErrTrailingGarbage    = errors.New("gzip: trailing garbage after the last member")
+)
+
+// DecodeError reports where in the compressed input the decoding failed.
+// Use errors.Is against the sentinel errors above to find out what went wrong.
+type DecodeError struct {
+	Err    error // the underlying error, wrapping one of the sentinel errors
+	Offset int64 // offset of the compressed byte being decoded
+	Bit    int   // position within that byte, 0 is the LSB (the first bit to be read)
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("%v (at byte %d, bit %d)", e.Err, e.Offset, e.Bit)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// decodeError annotates err with the current position of stream.
+// Running out of input in the middle of the stream is always reported as ErrUnexpectedEOF.
+func decodeError(stream *bitstream, err error) error {
+	var decodeErr *DecodeError
+	if errors.As(err, &decodeErr) {
+		return err // already annotated
+	}
+	if err == io.EOF {
+		err = ErrUnexpectedEOF
+	}
+	offset, bit := bitPosition(stream)
+	return &DecodeError{Err: err, Offset: offset, Bit: bit}
+}
@@ -0,0 +1,91 @@
+package gzip
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+/*
+The extra field (FEXTRA) is made of subfields, each one being:
+
+	SI1, SI2 (1 byte each): ID of the subfield, e.g. 'B' 'C' for the block size of BGZF, 'A' 'p' for Apollo files
+	LEN (2 bytes): length of the data
+	LEN bytes of data
+
+The IDs with SI2 = 0 are reserved by the RFC. XLEN, the length of the whole extra field, is 2 bytes as well, so the
+subfields together can't be longer than 64 KiB.
+*/
+
+// ExtraSubfield is one subfield of the extra field
+type ExtraSubfield struct {
+	ID   [2]byte
+	Data []byte
+}
+
+// ExtraField is the list of subfields of the extra field, in order
+type ExtraField []ExtraSubfield
+
+// ParseExtraField splits the extra field into its subfields, checking that they fill it exactly
+func ParseExtraField(extra []byte) (ExtraField, error) {
+	field := ExtraField{}
+	for offset := 0; offset < len(extra); {
+		if len(extra)-offset < 4 {
+			return nil, fmt.Errorf("%w: %d trailing bytes", ErrInvalidExtra, len(extra)-offset)
+		}
+		subfield := ExtraSubfield{ID: [2]byte{extra[offset], extra[offset+1]}}
+		if subfield.ID[1] == 0 {
+			return nil, fmt.Errorf("%w: reserved subfield ID %q", ErrInvalidExtra, subfield.ID[:])
+		}
+		length := int(binary.LittleEndian.Uint16(extra[offset+2:]))
+		offset += 4
+		if offset+length > len(extra) {
+			return nil, fmt.Errorf("%w: subfield %q of %d bytes, only %d left", ErrInvalidExtra, subfield.ID[:], length, len(extra)-offset)
+		}
+		subfield.Data = extra[offset : offset+length]
+		offset += length
+		field = append(field, subfield)
+	}
+	return field, nil
+}
+
+// Bytes serializes the subfields, the reverse of ParseExtraField
+func (field ExtraField) Bytes() ([]byte, error) {
+	extra := []byte{}
+	for _, subfield := range field {
+		if subfield.ID[1] == 0 {
+			return nil, fmt.Errorf("%w: reserved subfield ID %q", ErrInvalidExtra, subfield.ID[:])
+		}
+		if len(subfield.Data) > math.MaxUint16 {
+			return nil, fmt.Errorf("%w: subfield %q too long", ErrInvalidExtra, subfield.ID[:])
+		}
+		extra = append(extra, subfield.ID[0], subfield.ID[1], 0, 0)
+		binary.LittleEndian.PutUint16(extra[len(extra)-2:], uint16(len(subfield.Data)))
+		extra = append(extra, subfield.Data...)
+	}
+	if len(extra) > math.MaxUint16 {
+		return nil, fmt.Errorf("%w: %d bytes, XLEN is 2 bytes", ErrInvalidExtra, len(extra))
+	}
+	return extra, nil
+}
+
+// Get returns the data of the first subfield with the ID si1, si2
+func (field ExtraField) Get(si1, si2 byte) ([]byte, bool) {
+	for _, subfield := range field {
+		if subfield.ID == [2]byte{si1, si2} {
+			return subfield.Data, true
+		}
+	}
+	return nil, false
+}
+
+// GetAll returns the data of all the subfields with the ID si1, si2 (a subfield may be repeated)
+func (field ExtraField) GetAll(si1, si2 byte) [][]byte {
+	var all [][]byte
+	for _, subfield := range field {
+		if subfield.ID == [2]byte{si1, si2} {
+			all = append(all, subfield.Data)
+		}
+	}
+	return all
+}
@@ -0,0 +1,86 @@
+package gzip
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseExtraField(t *testing.T) {
+	extra := []byte{'A', 'p', 2, 0, 1, 2, 'B', 'C', 0, 0, 'A', 'p', 1, 0, 3}
+	field, err := ParseExtraField(extra)
+	assert.NoError(t, err)
+	assert.Equal(t, ExtraField{
+		{ID: [2]byte{'A', 'p'}, Data: []byte{1, 2}},
+		{ID: [2]byte{'B', 'C'}, Data: []byte{}},
+		{ID: [2]byte{'A', 'p'}, Data: []byte{3}},
+	}, field)
+
+	data, ok := field.Get('A', 'p')
+	assert.True(t, ok)
+	assert.Equal(t, []byte{1, 2}, data)
+	assert.Equal(t, [][]byte{{1, 2}, {3}}, field.GetAll('A', 'p'))
+	_, ok = field.Get('X', 'Y')
+	assert.False(t, ok)
+
+	serialized, err := field.Bytes()
+	assert.NoError(t, err)
+	assert.Equal(t, extra, serialized)
+
+	field, err = ParseExtraField(nil)
+	assert.NoError(t, err)
+	assert.Empty(t, field)
+}
+
+func TestParseExtraFieldInvalid(t *testing.T) {
+	testCases := []struct {
+		name  string
+		extra []byte
+	}{
+		{"truncated subfield header", []byte{'A', 'p', 2}},
+		{"data too long", []byte{'A', 'p', 3, 0, 1, 2}},
+		{"trailing bytes", []byte{'A', 'p', 1, 0, 1, 0}},
+		{"reserved ID", []byte{'A', 0, 0, 0}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ParseExtraField(tc.extra)
+			assert.ErrorIs(t, err, ErrInvalidExtra)
+		})
+	}
+
+	_, err := ExtraField{{ID: [2]byte{'A', 'p'}, Data: make([]byte, 1<<16)}}.Bytes()
+	assert.ErrorIs(t, err, ErrInvalidExtra)
+	_, err = ExtraField{{ID: [2]byte{'A', 'p'}, Data: make([]byte, 40000)}, {ID: [2]byte{'A', 'q'}, Data: make([]byte, 40000)}}.Bytes()
+	assert.ErrorIs(t, err, ErrInvalidExtra)
+
+	// the metadata of the member is still read, without the subfields (see TestReadGzipMalformedExtra)
+	metadata := []byte{0x1F, 0x8B, 0x08, FEXTRA, 0, 0, 0, 0, 0, 0xFF, 3, 0, 'A', 'p', 1}
+	gzipMetaData, err := readGzipMetaData(&bitstream{source: bytes.NewReader(metadata)})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{'A', 'p', 1}, gzipMetaData.Extra)
+	assert.Nil(t, gzipMetaData.Subfields)
+}
+
+// an extra field longer than 32 KiB, which didn't fit in the int16 XLEN there used to be
+func TestLongExtraField(t *testing.T) {
+	subfields := ExtraField{
+		{ID: [2]byte{'L', 'o'}, Data: bytes.Repeat([]byte{'x'}, 40000)},
+		{ID: [2]byte{'n', 'g'}, Data: []byte("long")},
+	}
+	var compressed bytes.Buffer
+	writer := NewWriter(&compressed)
+	writer.Header.Subfields = subfields
+	_, err := writer.Write([]byte("let it be"))
+	assert.NoError(t, err)
+	assert.NoError(t, writer.Close())
+
+	reader, err := NewReader(&compressed)
+	assert.NoError(t, err)
+	assert.Equal(t, uint16(40012), reader.Header.Xlen)
+	assert.Equal(t, subfields, reader.Header.Subfields)
+	data, ok := reader.Header.Subfields.Get('n', 'g')
+	assert.True(t, ok)
+	assert.Equal(t, []byte("long"), data)
+}
@@ -0,0 +1,192 @@
+// Package gzip decodes gzip files (RFC 1952), along with the deflate stream (RFC 1951) inside them.
+// It is written to be read: the decoder can explain the structure of the stream as it goes (see Decoder).
+package gzip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"math"
+)
+
+type GzipHeader struct {
+	ID                [2]byte
+	CompressionMethod byte
+	Flags             byte
+	Mtime             [4]byte
+	ExtraFlags        byte
+	OS                byte
+}
+
+type GzipMetaData struct {
+	Header GzipHeader
+	Xlen   uint16
+	Extra  []byte
+	// Subfields is Extra split into its subfields, nil if Extra isn't made of valid subfields (ParseExtraField
+	// tells what's wrong with it, the file can be inflated anyway). When writing, the Writer serializes Subfields
+	// into Extra if it is set, otherwise Extra is written as it is.
+	Subfields ExtraField
+	Fname     []byte
+	Fcomment  []byte
+	Crc16     uint16
+}
+
+// GzipTrailer follows the deflate stream of every gzip member
+type GzipTrailer struct {
+	Crc32 uint32 // CRC-32 of the uncompressed data
+	Isize uint32 // size of the uncompressed data modulo 2^32
+}
+
+const FTEXT byte = 0x01
+const FHCRC byte = 0x02
+const FEXTRA byte = 0x04
+const FNAME byte = 0x08
+const FCOMMENT byte = 0x10
+
+// readCString reads a zero-terminated string from source, stream is only there to locate the errors
+func readCString(stream *bitstream, source io.Reader) (buf []byte, err error) {
+	var nextChar byte
+	if err := binary.Read(source, binary.LittleEndian, &nextChar); err != nil {
+		return nil, decodeError(stream, err)
+	}
+	for nextChar != 0x00 {
+		buf = append(buf, nextChar)
+		if err := binary.Read(source, binary.LittleEndian, &nextChar); err != nil {
+			return nil, decodeError(stream, err)
+		}
+	}
+	return buf, nil
+}
+
+func readGzipMetaData(stream *bitstream) (GzipMetaData, error) {
+	gzipMetaData := GzipMetaData{}
+	// everything before the CRC-16 goes through the digest, in case there is one to verify
+	digest := crc32.NewIEEE()
+	source := io.TeeReader(stream, digest)
+	if err := binary.Read(source, binary.LittleEndian, &gzipMetaData.Header); err != nil {
+		return gzipMetaData, decodeError(stream, err)
+	}
+	if !(gzipMetaData.Header.ID[0] == 0x1f && gzipMetaData.Header.ID[1] == 0x8b) {
+		return gzipMetaData, decodeError(stream, ErrBadMagic)
+	}
+	if gzipMetaData.Header.CompressionMethod != 8 {
+		return gzipMetaData, decodeError(stream, fmt.Errorf("%w: %d", ErrUnsupportedMethod, gzipMetaData.Header.CompressionMethod))
+	}
+	if (gzipMetaData.Header.Flags & FEXTRA) != 0 {
+		if err := binary.Read(source, binary.LittleEndian, &gzipMetaData.Xlen); err != nil {
+			return gzipMetaData, decodeError(stream, err)
+		}
+		gzipMetaData.Extra = make([]byte, gzipMetaData.Xlen)
+		if err := binary.Read(source, binary.LittleEndian, &gzipMetaData.Extra); err != nil {
+			return gzipMetaData, decodeError(stream, err)
+		}
+		// like gzip, malformed subfields don't prevent the inflation, they are only left out of Subfields
+		gzipMetaData.Subfields, _ = ParseExtraField(gzipMetaData.Extra)
+	}
+	var err error
+	if (gzipMetaData.Header.Flags & FNAME) != 0 {
+		if gzipMetaData.Fname, err = readCString(stream, source); err != nil {
+			return gzipMetaData, err
+		}
+	}
+	if (gzipMetaData.Header.Flags & FCOMMENT) != 0 {
+		if gzipMetaData.Fcomment, err = readCString(stream, source); err != nil {
+			return gzipMetaData, err
+		}
+	}
+	if (gzipMetaData.Header.Flags & FHCRC) != 0 {
+		if err := binary.Read(stream, binary.LittleEndian, &gzipMetaData.Crc16); err != nil {
+			return gzipMetaData, decodeError(stream, err)
+		}
+		// the CRC-16 is the lower half of the CRC-32 of the header bytes
+		if checksum := uint16(digest.Sum32()); checksum != gzipMetaData.Crc16 {
+			return gzipMetaData, decodeError(stream, fmt.Errorf("%w: header CRC-16 %04x, expected %04x", ErrChecksum, checksum, gzipMetaData.Crc16))
+		}
+	}
+	return gzipMetaData, nil
+}
+
+func readGzipTrailer(stream *bitstream) (GzipTrailer, error) {
+	// the trailer starts at the byte boundary after the final block
+	alignToByte(stream)
+	trailer := GzipTrailer{}
+	if err := binary.Read(stream, binary.LittleEndian, &trailer); err != nil {
+		return trailer, decodeError(stream, err)
+	}
+	return trailer, nil
+}
+
+func verifyGzipTrailer(trailer GzipTrailer, checksum uint32, size uint32) error {
+	if checksum != trailer.Crc32 {
+		return fmt.Errorf("%w: CRC-32 %08x, expected %08x", ErrChecksum, checksum, trailer.Crc32)
+	}
+	if size != trailer.Isize { // ISIZE is the size modulo 2^32
+		return fmt.Errorf("%w: got %d, expected %d", ErrSize, size, trailer.Isize)
+	}
+	return nil
+}
+
+// writeGzipMetaData writes the header of a member. ID, CompressionMethod and the flags of the optional fields are
+// set according to gzipMetaData, the other fields are written as they are. If FHCRC is set in the flags, the CRC-16
+// of the header is computed and written as well.
+func writeGzipMetaData(writer *bitWriter, gzipMetaData GzipMetaData) error {
+	if gzipMetaData.Subfields != nil {
+		var err error
+		if gzipMetaData.Extra, err = gzipMetaData.Subfields.Bytes(); err != nil {
+			return err
+		}
+	}
+	header := gzipMetaData.Header
+	header.ID = [2]byte{0x1f, 0x8b}
+	header.CompressionMethod = 8
+	header.Flags &= FTEXT | FHCRC
+	if gzipMetaData.Extra != nil {
+		header.Flags |= FEXTRA
+	}
+	if gzipMetaData.Fname != nil {
+		header.Flags |= FNAME
+	}
+	if gzipMetaData.Fcomment != nil {
+		header.Flags |= FCOMMENT
+	}
+	// the header is put together in buf first (writing to it can't fail), so its CRC-16 can be computed
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, header)
+	if gzipMetaData.Extra != nil {
+		if len(gzipMetaData.Extra) > math.MaxUint16 {
+			return fmt.Errorf("%w: %d bytes, XLEN is 2 bytes", ErrInvalidExtra, len(gzipMetaData.Extra))
+		}
+		binary.Write(&buf, binary.LittleEndian, uint16(len(gzipMetaData.Extra)))
+		buf.Write(gzipMetaData.Extra)
+	}
+	for _, field := range [][]byte{gzipMetaData.Fname, gzipMetaData.Fcomment} {
+		if field == nil {
+			continue
+		}
+		if bytes.IndexByte(field, 0) >= 0 {
+			return errors.New("gzip: file name and comment can't contain a zero byte")
+		}
+		buf.Write(append(field[:len(field):len(field)], 0))
+	}
+	if header.Flags&FHCRC != 0 {
+		binary.Write(&buf, binary.LittleEndian, uint16(crc32.ChecksumIEEE(buf.Bytes())))
+	}
+	_, err := writer.Write(buf.Bytes())
+	return err
+}
+
+func writeGzipTrailer(writer *bitWriter, trailer GzipTrailer) error {
+	alignWriter(writer)
+	return binary.Write(writer, binary.LittleEndian, trailer)
+}
+
+func readGzipFile(file io.Reader) ([]byte, error) {
+	reader, err := NewReader(file)
+	if err != nil {
+		return nil, err
+	}
+	return io.ReadAll(reader)
+}
@@ -0,0 +1,173 @@
+package gzip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"os"
+	"testing"
+)
+
+func TestReadGzipHeader(t *testing.T) {
+	expectedGzipHeader := GzipHeader{
+		ID:                [2]byte{0x01, 0x02},
+		CompressionMethod: 0x03,
+		Flags:             0x04,
+		Mtime:             [4]byte{0x05, 0x06, 0x07, 0x08},
+		ExtraFlags:        0x09,
+		OS:                0x0A,
+	}
+	file := bytes.NewReader([]byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0A})
+	gzipHeader := GzipHeader{}
+	assert.NoError(t, binary.Read(file, binary.LittleEndian, &gzipHeader))
+	assert.Equal(t, expectedGzipHeader, gzipHeader)
+}
+
+func TestReadGzipMetaData(t *testing.T) {
+	expectedGzipMetaData := GzipMetaData{
+		Header: GzipHeader{
+			ID:                [2]byte{0x1F, 0x8B},
+			CompressionMethod: 0x08,
+			Flags:             0x1F, // enable everything
+			Mtime:             [4]byte{0x05, 0x06, 0x07, 0x08},
+			ExtraFlags:        0x09,
+			OS:                0x0A,
+		},
+		Xlen:     0x0008, // 8 bytes of extra data
+		Extra:    []byte{0x41, 0x42, 0x04, 0x00, 0x04, 0x05, 0x06, 0x07},
+		Fname:    []byte{0x61, 0x62, 0x63},
+		Fcomment: []byte{0x65, 0x66, 0x67},
+		Crc16:    uint16(0xF024), // lower half of the CRC-32 of the bytes above
+	}
+	metadata := []byte{
+		0x1F, 0x8B, 0x08, 0x1F, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0A, // header
+		0x08, 0x00, // xlen uint16
+		0x41, 0x42, 0x04, 0x00, 0x04, 0x05, 0x06, 0x07, // extra data (xlen bytes): subfield 'AB' of 4 bytes
+		0x61, 0x62, 0x63, 0x00, // Fname (c-string) 'abc\0'
+		0x65, 0x66, 0x67, 0x00, // Fcomment (c-string) 'efg\0'
+		0x24, 0xF0, // CRC16 (uint16)
+	}
+	gzipFile, err := readGzipMetaData(&bitstream{source: bytes.NewReader(metadata)})
+	assert.NoError(t, err)
+	assert.Equal(t, expectedGzipMetaData.Header, gzipFile.Header)
+	assert.Equal(t, expectedGzipMetaData.Xlen, gzipFile.Xlen)
+	assert.Equal(t, expectedGzipMetaData.Extra, gzipFile.Extra)
+	assert.Equal(t, ExtraField{{ID: [2]byte{'A', 'B'}, Data: []byte{0x04, 0x05, 0x06, 0x07}}}, gzipFile.Subfields)
+	assert.Equal(t, expectedGzipMetaData.Fname, gzipFile.Fname)
+	assert.Equal(t, expectedGzipMetaData.Fcomment, gzipFile.Fcomment)
+	assert.Equal(t, expectedGzipMetaData.Crc16, gzipFile.Crc16)
+}
+
+func TestReadGzipFileCorrupted(t *testing.T) {
+	original, err := os.ReadFile("../attachment/let_it_be_stored.txt.gz")
+	if err != nil {
+		panic(err)
+	}
+	testCases := []struct {
+		name          string
+		offset        int // offset of the byte to corrupt
+		expectedError error
+	}{
+		{"data", len(original) - 20, ErrChecksum},
+		{"crc32", len(original) - 8, ErrChecksum},
+		{"isize", len(original) - 1, ErrSize},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			corrupted := append([]byte{}, original...)
+			corrupted[tc.offset] ^= 0x01
+			_, err := readGzipFile(bytes.NewReader(corrupted))
+			assert.ErrorIs(t, err, tc.expectedError)
+		})
+	}
+}
+
+func TestReadGzipMembers(t *testing.T) {
+	expected, err := os.ReadFile("../attachment/let_it_be.txt")
+	if err != nil {
+		panic(err)
+	}
+	stored, err := os.ReadFile("../attachment/let_it_be_stored.txt.gz")
+	if err != nil {
+		panic(err)
+	}
+	compressed, err := os.ReadFile("../attachment/let_it_be.txt.gz")
+	if err != nil {
+		panic(err)
+	}
+	// equivalent to `cat let_it_be_stored.txt.gz let_it_be.txt.gz`
+	concatenated := append(append([]byte{}, stored...), compressed...)
+
+	reader, err := NewReader(bytes.NewReader(concatenated))
+	assert.NoError(t, err)
+	assert.Nil(t, reader.Header.Fname)
+
+	first := make([]byte, len(expected))
+	_, err = io.ReadFull(reader, first)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, first)
+
+	// moving on to the second member
+	second, err := io.ReadAll(reader)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, second)
+	assert.Equal(t, FNAME, reader.Header.Header.Flags&FNAME)
+	assert.Equal(t, []byte("let_it_be.txt"), reader.Header.Fname)
+}
+
+func TestReadGzipFileErrors(t *testing.T) {
+	original, err := os.ReadFile("../attachment/let_it_be.txt.gz")
+	if err != nil {
+		panic(err)
+	}
+	testCases := []struct {
+		name           string
+		input          []byte
+		expectedError  error
+		expectedOffset int64
+	}{
+		{"bad magic", append([]byte{0x1f, 0x8c}, original[2:]...), ErrBadMagic, 10},
+		{"unsupported method", append([]byte{0x1f, 0x8b, 0x07}, original[3:]...), ErrUnsupportedMethod, 10},
+		{"invalid block type", append(append([]byte{}, original[:24]...), 0b111), ErrInvalidBlockType, 24},
+		{"truncated name", original[:15], ErrUnexpectedEOF, 15},
+		{"truncated trailer", original[:len(original)-3], ErrUnexpectedEOF, int64(len(original) - 3)},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := readGzipFile(bytes.NewReader(tc.input))
+			assert.ErrorIs(t, err, tc.expectedError)
+
+			var decodeErr *DecodeError
+			if assert.ErrorAs(t, err, &decodeErr) {
+				assert.Equal(t, tc.expectedOffset, decodeErr.Offset)
+			}
+		})
+	}
+}
+
+func TestReadGzipMalformedExtra(t *testing.T) {
+	for _, extra := range [][]byte{
+		{'A', 0, 1, 0, 9},      // reserved SI2
+		{'A', 'B', 9, 0, 1, 2}, // longer than XLEN
+		{'A', 'B', 0, 0, 'C'},  // trailing byte
+	} {
+		var out bytes.Buffer
+		writer := NewWriter(&out)
+		writer.Header.Extra = extra
+		_, err := writer.Write([]byte("hello"))
+		assert.NoError(t, err)
+		assert.NoError(t, writer.Close())
+
+		// the file is inflated anyway, the malformed subfields are only left out
+		reader, err := NewReader(bytes.NewReader(out.Bytes()))
+		assert.NoError(t, err)
+		data, err := io.ReadAll(reader)
+		assert.NoError(t, err)
+		assert.Equal(t, []byte("hello"), data)
+		assert.Equal(t, extra, reader.Header.Extra)
+		assert.Nil(t, reader.Header.Subfields)
+		_, err = ParseExtraField(reader.Header.Extra)
+		assert.ErrorIs(t, err, ErrInvalidExtra)
+	}
+}
@@ -0,0 +1,162 @@
+package gzip
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// OS is the file system the gzip file was made on (it tells e.g. how the end of lines are written)
+type OS byte
+
+const (
+	OSFAT         OS = 0 // MS-DOS, OS/2, NT/Win32
+	OSAmiga       OS = 1
+	OSVMS         OS = 2 // or OpenVMS
+	OSUnix        OS = 3
+	OSVMCMS       OS = 4
+	OSAtariTOS    OS = 5
+	OSHPFS        OS = 6 // OS/2, NT
+	OSMacintosh   OS = 7
+	OSZSystem     OS = 8
+	OSCPM         OS = 9
+	OSTOPS20      OS = 10
+	OSNTFS        OS = 11 // NT
+	OSQDOS        OS = 12
+	OSAcornRISCOS OS = 13
+	OSUnknown     OS = 255
+)
+
+var osNames = map[OS]string{
+	OSFAT:         "FAT",
+	OSAmiga:       "Amiga",
+	OSVMS:         "VMS",
+	OSUnix:        "Unix",
+	OSVMCMS:       "VM/CMS",
+	OSAtariTOS:    "Atari TOS",
+	OSHPFS:        "HPFS",
+	OSMacintosh:   "Macintosh",
+	OSZSystem:     "Z-System",
+	OSCPM:         "CP/M",
+	OSTOPS20:      "TOPS-20",
+	OSNTFS:        "NTFS",
+	OSQDOS:        "QDOS",
+	OSAcornRISCOS: "Acorn RISCOS",
+	OSUnknown:     "unknown",
+}
+
+func (system OS) String() string {
+	if name, ok := osNames[system]; ok {
+		return name
+	}
+	return fmt.Sprintf("OS(%d)", byte(system))
+}
+
+// Header is the metadata of a gzip member, decoded from its GzipMetaData (see DecodeHeader and EncodeHeader)
+type Header struct {
+	Name       string    // FNAME, the name of the original file
+	Comment    string    // FCOMMENT
+	ModTime    time.Time // MTIME, the zero time if there isn't any (MTIME is 0)
+	OS         OS
+	Text       bool // FTEXT, the data is probably text
+	ExtraFlags byte // XFL, 2 if the slowest compression was used, 4 if the fastest was (set by the Writer)
+	Extra      ExtraField
+	HeaderCRC  bool // FHCRC, the header is followed by its CRC-16
+}
+
+// DecodeHeader decodes the fields of the metadata. The name and comment are ISO-8859-1 (Latin-1), they are
+// turned into UTF-8. Extra is nil if the extra field is malformed (see GzipMetaData.Subfields).
+func DecodeHeader(gzipMetaData GzipMetaData) Header {
+	header := Header{
+		Name:       decodeLatin1(gzipMetaData.Fname),
+		Comment:    decodeLatin1(gzipMetaData.Fcomment),
+		OS:         OS(gzipMetaData.Header.OS),
+		Text:       gzipMetaData.Header.Flags&FTEXT != 0,
+		ExtraFlags: gzipMetaData.Header.ExtraFlags,
+		Extra:      gzipMetaData.Subfields,
+		HeaderCRC:  gzipMetaData.Header.Flags&FHCRC != 0,
+	}
+	if mtime := binary.LittleEndian.Uint32(gzipMetaData.Header.Mtime[:]); mtime != 0 {
+		header.ModTime = time.Unix(int64(mtime), 0)
+	}
+	return header
+}
+
+// EncodeHeader is the reverse of DecodeHeader. It fails if the name or comment has characters outside of
+// ISO-8859-1 (or a zero byte), or if the modification time doesn't fit in MTIME (before 1970 or after 2106).
+func EncodeHeader(header Header) (GzipMetaData, error) {
+	gzipMetaData := GzipMetaData{Subfields: header.Extra}
+	gzipMetaData.Header.OS = byte(header.OS)
+	gzipMetaData.Header.ExtraFlags = header.ExtraFlags
+	if header.Text {
+		gzipMetaData.Header.Flags |= FTEXT
+	}
+	if header.HeaderCRC {
+		gzipMetaData.Header.Flags |= FHCRC
+	}
+	if !header.ModTime.IsZero() {
+		mtime := header.ModTime.Unix()
+		if mtime <= 0 || mtime > math.MaxUint32 {
+			return gzipMetaData, fmt.Errorf("gzip: modification time %v doesn't fit in the header", header.ModTime)
+		}
+		binary.LittleEndian.PutUint32(gzipMetaData.Header.Mtime[:], uint32(mtime))
+	}
+	var err error
+	if gzipMetaData.Fname, err = encodeLatin1(header.Name); err != nil {
+		return gzipMetaData, fmt.Errorf("gzip: file name %q: %w", header.Name, err)
+	}
+	if gzipMetaData.Fcomment, err = encodeLatin1(header.Comment); err != nil {
+		return gzipMetaData, fmt.Errorf("gzip: comment %q: %w", header.Comment, err)
+	}
+	return gzipMetaData, nil
+}
+
+func (header Header) String() string {
+	var fields []string
+	if header.Name != "" {
+		fields = append(fields, fmt.Sprintf("name %q", header.Name))
+	}
+	if header.Comment != "" {
+		fields = append(fields, fmt.Sprintf("comment %q", header.Comment))
+	}
+	if !header.ModTime.IsZero() {
+		fields = append(fields, "modified "+header.ModTime.UTC().Format(time.RFC3339))
+	}
+	fields = append(fields, "OS "+header.OS.String())
+	if header.Text {
+		fields = append(fields, "text")
+	}
+	for _, subfield := range header.Extra {
+		fields = append(fields, fmt.Sprintf("extra subfield %q (%d bytes)", subfield.ID[:], len(subfield.Data)))
+	}
+	if header.HeaderCRC {
+		fields = append(fields, "header CRC-16")
+	}
+	return strings.Join(fields, ", ")
+}
+
+// decodeLatin1 turns ISO-8859-1 into UTF-8: each byte is the code point of its character
+func decodeLatin1(latin1 []byte) string {
+	runes := make([]rune, len(latin1))
+	for i, b := range latin1 {
+		runes[i] = rune(b)
+	}
+	return string(runes)
+}
+
+// encodeLatin1 is the reverse of decodeLatin1, it returns nil for an empty string (the field isn't written)
+func encodeLatin1(s string) ([]byte, error) {
+	if s == "" {
+		return nil, nil
+	}
+	latin1 := make([]byte, 0, len(s))
+	for _, r := range s {
+		if r == 0 || r > 0xFF {
+			return nil, fmt.Errorf("character %q can't be written in ISO-8859-1", r)
+		}
+		latin1 = append(latin1, byte(r))
+	}
+	return latin1, nil
+}
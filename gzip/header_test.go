@@ -0,0 +1,94 @@
+package gzip
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDecodeHeader(t *testing.T) {
+	file, err := os.Open("../attachment/gunzip.c.gz")
+	assert.NoError(t, err)
+	defer file.Close()
+	reader, err := NewReader(file)
+	assert.NoError(t, err)
+	header := DecodeHeader(reader.Header)
+	assert.Equal(t, "gunzip.c", header.Name)
+	assert.Equal(t, OSUnix, header.OS)
+	assert.Equal(t, "Unix", header.OS.String())
+	assert.Equal(t, time.Date(2011, 4, 27, 16, 21, 54, 0, time.UTC), header.ModTime.UTC())
+	assert.False(t, header.HeaderCRC)
+
+	assert.Equal(t, "OS(42)", OS(42).String())
+	assert.True(t, DecodeHeader(GzipMetaData{}).ModTime.IsZero())
+}
+
+func TestHeaderRoundTrip(t *testing.T) {
+	header := Header{
+		Name:      "café.txt",
+		Comment:   "½ déjà vu",
+		ModTime:   time.Date(2024, 2, 29, 12, 0, 0, 0, time.UTC),
+		OS:        OSMacintosh,
+		Text:      true,
+		Extra:     ExtraField{{ID: [2]byte{'A', 'p'}, Data: []byte("apollo")}},
+		HeaderCRC: true,
+	}
+	var compressed bytes.Buffer
+	writer := NewWriter(&compressed)
+	assert.NoError(t, writer.SetHeader(header))
+	_, err := writer.Write([]byte("let it be"))
+	assert.NoError(t, err)
+	assert.NoError(t, writer.Close())
+	assert.Error(t, writer.SetHeader(header))
+
+	// the name is written in ISO-8859-1, é is a single byte
+	assert.True(t, bytes.Contains(compressed.Bytes(), []byte("caf\xe9.txt\x00")))
+
+	reader, err := NewReader(bytes.NewReader(compressed.Bytes()))
+	assert.NoError(t, err)
+	decoded := DecodeHeader(reader.Header)
+	assert.Equal(t, header.Name, decoded.Name)
+	assert.Equal(t, header.Comment, decoded.Comment)
+	assert.True(t, header.ModTime.Equal(decoded.ModTime))
+	assert.Equal(t, header.OS, decoded.OS)
+	assert.True(t, decoded.Text)
+	assert.True(t, decoded.HeaderCRC)
+	assert.Equal(t, header.Extra, decoded.Extra)
+	data, err := io.ReadAll(reader)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("let it be"), data)
+}
+
+func TestHeaderCRCMismatch(t *testing.T) {
+	var compressed bytes.Buffer
+	writer := NewWriter(&compressed)
+	assert.NoError(t, writer.SetHeader(Header{Name: "let_it_be.txt", HeaderCRC: true}))
+	assert.NoError(t, writer.Close())
+
+	corrupted := compressed.Bytes()
+	corrupted[10] ^= 0x01 // first letter of the name
+	_, err := NewReader(bytes.NewReader(corrupted))
+	assert.ErrorIs(t, err, ErrChecksum)
+}
+
+func TestEncodeHeaderInvalid(t *testing.T) {
+	testCases := []struct {
+		name   string
+		header Header
+	}{
+		{"name outside of ISO-8859-1", Header{Name: "日本.txt"}},
+		{"zero byte in the comment", Header{Comment: "a\x00b"}},
+		{"modification time before 1970", Header{ModTime: time.Date(1969, 1, 1, 0, 0, 0, 0, time.UTC)}},
+		{"modification time after 2106", Header{ModTime: time.Date(2107, 1, 1, 0, 0, 0, 0, time.UTC)}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := EncodeHeader(tc.header)
+			assert.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,404 @@
+package gzip
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+type huffmanNode struct {
+	code int // -1 for non-leaf nodes
+	zero *huffmanNode
+	one  *huffmanNode
+}
+
+type treeNode struct {
+	len  int
+	code int
+}
+
+// assignHuffmanCodes assigns the canonical huffman code of each symbol (tree index), given the bit lengths
+func assignHuffmanCodes(hRanges []rleRange) (tree []treeNode, maxBitLength int, err error) {
+	// 1. find max bit length
+	for _, hRange := range hRanges {
+		if hRange.bitLength > maxBitLength {
+			maxBitLength = hRange.bitLength
+		}
+	}
+
+	// (2. allocate space (skipped, will allocate near the logic itself))
+
+	// 3. Determine the number of codes for each bit-length
+	blCount := map[int]int{} // number of codes for each bit length
+	previousEnd := -1
+	for _, hRange := range hRanges {
+		if hRange.end-previousEnd <= 0 {
+			return nil, 0, errors.New("the end of each rleRange must be strictly increasing")
+		}
+		count := hRange.end - previousEnd
+		if hRange.bitLength > 0 { // symbols with 0 bit length are not part of the tree
+			blCount[hRange.bitLength] += count
+		}
+		previousEnd = hRange.end
+	}
+
+	// 4. Populate nextCode (the starting `code` for each bit length group)
+	nextCode := map[int]int{} // the starting 'code' for each bit length group
+	code := 0
+	for bitLength := 1; bitLength <= maxBitLength; bitLength++ {
+		// the previous starting point was code, we increment it by the bitLengthCount of
+		//   the previous group, then we right shift it by 1.
+		code = (code + blCount[bitLength-1]) << 1
+
+		nextCode[bitLength] = code
+
+		if code+blCount[bitLength] > 1<<bitLength {
+			// the bit lengths describe more codes than what fits in bitLength bits
+			return nil, 0, fmt.Errorf("%w: over-subscribed code lengths", ErrInvalidHuffmanTree)
+		}
+	}
+
+	// 5. assign codes to each symbol in range
+	numberOfCodes := hRanges[len(hRanges)-1].end
+	tree = make([]treeNode, numberOfCodes+1) // symbol start from zero
+	hRangeIdx := 0
+	for ti := 0; ti <= numberOfCodes; ti++ { // ti for tree index
+		hRange := hRanges[hRangeIdx]
+		if ti > hRange.end {
+			// move to the next range
+			hRangeIdx++
+			hRange = hRanges[hRangeIdx]
+		}
+
+		tree[ti].len = hRange.bitLength
+		tree[ti].code = nextCode[tree[ti].len]
+		nextCode[tree[ti].len]++
+	}
+	return tree, maxBitLength, nil
+}
+
+func buildHuffmanTree(hRanges []rleRange) (*huffmanNode, error) {
+	tree, _, err := assignHuffmanCodes(hRanges)
+	if err != nil {
+		return nil, err
+	}
+	numberOfCodes := len(tree) - 1
+
+	// 6. build huffman tree
+	root := &huffmanNode{code: -1}
+	for ti := 0; ti <= numberOfCodes; ti++ {
+		var node *huffmanNode
+		node = root
+		if tree[ti].len == 0 {
+			continue
+		}
+		// traverse the tree, build node if not exist; bi is bitIndex
+		for bi := tree[ti].len; bi > 0; bi-- {
+			if (tree[ti].code & (1 << (bi - 1))) > 0 { // if the bi-th bit is set
+				if node.one == nil {
+					node.one = &huffmanNode{code: -1}
+				}
+				node = node.one
+			} else {
+				if node.zero == nil {
+					node.zero = &huffmanNode{code: -1}
+				}
+				node = node.zero
+			}
+		}
+		if node.code != -1 {
+			panic("this node shouldn't be set before")
+		}
+		node.code = ti
+	}
+	return root, nil
+}
+
+func traverseHuffmanTree(node *huffmanNode, prefix string, codeTable []string) {
+	if node.code != -1 {
+		codeTable[node.code] = prefix
+	}
+	if node.one != nil {
+		traverseHuffmanTree(node.one, prefix+"1", codeTable)
+	}
+	if node.zero != nil {
+		traverseHuffmanTree(node.zero, prefix+"0", codeTable)
+	}
+}
+
+func debugPrintHuffmanTree(node *huffmanNode, memberCount int) {
+	codes := make([]string, memberCount)
+	traverseHuffmanTree(node, "", codes)
+	for i, v := range codes {
+		if v != "" {
+			fmt.Printf("code %d %s\n", i+1, v)
+		}
+	}
+}
+
+func getCode(stream *bitstream, root *huffmanNode) (int, string, error) {
+	node := root
+	debugHuffmanCodeString := ""
+	for node.code == -1 {
+		bit, err := nextBit(stream)
+		if err != nil {
+			return 0, debugHuffmanCodeString, err
+		}
+		if bit == 0 {
+			node = node.zero
+			debugHuffmanCodeString += "0"
+		} else {
+			node = node.one
+			debugHuffmanCodeString += "1"
+		}
+		if node == nil {
+			return 0, debugHuffmanCodeString, decodeError(stream,
+				fmt.Errorf("%w: %s is not a valid huffman code / path", ErrInvalidHuffmanCode, debugHuffmanCodeString))
+		}
+	}
+	return node.code, debugHuffmanCodeString, nil
+}
+
+/*
+Walking the tree costs one step per bit. Instead, the decoder can peek primaryBits bits at once and look them up
+in a table holding, for every possible combination of those bits, the symbol whose code they start with and the
+length of that code (so we know how many bits to actually consume).
+
+Codes longer than primaryBits would make the table huge, so their entry in the primary table links to a smaller
+secondary table, indexed by the bits following the first primaryBits bits.
+
+As the bits are read LSB first while the huffman codes are written MSB first, the tables are indexed by the
+reversed codes.
+*/
+
+const (
+	primaryBits = 9
+
+	entryLengthMask  = 0x1F // bits 0-4: length of the code, 0 if the bits are not a valid code
+	entryLink        = 0x20 // bit 5: the entry links to a secondary table, the length bits are its index size
+	entryValueShift  = 6    // bits 6-31: the symbol, or the index of the secondary table
+	maxHuffmanLength = 16   // deflate only uses up to 15 bits
+)
+
+type huffmanTable struct {
+	primaryBits uint
+	primary     []uint32
+	secondary   [][]uint32
+}
+
+func reverseBits(code int, length int) int {
+	reversed := 0
+	for i := 0; i < length; i++ {
+		reversed = reversed<<1 | (code>>i)&1
+	}
+	return reversed
+}
+
+func buildHuffmanTable(hRanges []rleRange) (*huffmanTable, error) {
+	tree, maxBitLength, err := assignHuffmanCodes(hRanges)
+	if err != nil {
+		return nil, err
+	}
+	if maxBitLength > maxHuffmanLength {
+		return nil, fmt.Errorf("%w: code length %d", ErrInvalidHuffmanTree, maxBitLength)
+	}
+	table := &huffmanTable{primaryBits: primaryBits}
+	if maxBitLength < primaryBits {
+		table.primaryBits = uint(maxBitLength)
+	}
+	table.primary = make([]uint32, 1<<table.primaryBits)
+	pBits := int(table.primaryBits)
+
+	// 1. create the secondary tables, sized for the longest code starting with each prefix
+	secondaryBits := map[int]int{}
+	for _, node := range tree {
+		if node.len > pBits {
+			prefix := reverseBits(node.code>>(node.len-pBits), pBits)
+			if node.len-pBits > secondaryBits[prefix] {
+				secondaryBits[prefix] = node.len - pBits
+			}
+		}
+	}
+	for prefix := 0; prefix < len(table.primary); prefix++ {
+		if bits, ok := secondaryBits[prefix]; ok {
+			table.primary[prefix] = uint32(len(table.secondary))<<entryValueShift | entryLink | uint32(bits)
+			table.secondary = append(table.secondary, make([]uint32, 1<<bits))
+		}
+	}
+
+	// 2. fill in every combination of bits following each code
+	for ti, node := range tree {
+		if node.len == 0 {
+			continue
+		}
+		entry := uint32(ti)<<entryValueShift | uint32(node.len)
+		reversed := reverseBits(node.code, node.len)
+		if node.len <= pBits {
+			for i := reversed; i < len(table.primary); i += 1 << node.len {
+				table.primary[i] = entry
+			}
+		} else {
+			link := table.primary[reversed&(1<<pBits-1)]
+			secondary := table.secondary[link>>entryValueShift]
+			for i := reversed >> pBits; i < len(secondary); i += 1 << (node.len - pBits) {
+				secondary[i] = entry
+			}
+		}
+	}
+	return table, nil
+}
+
+// lookupCode decodes the next symbol (tree index) using the tables
+func lookupCode(stream *bitstream, table *huffmanTable) (int, error) {
+	bits, available, err := peekBits(stream, table.primaryBits)
+	if err != nil {
+		return 0, err
+	}
+	needed := table.primaryBits
+	entry := table.primary[bits]
+	if entry&entryLink != 0 {
+		needed += uint(entry & entryLengthMask)
+		if bits, available, err = peekBits(stream, needed); err != nil {
+			return 0, err
+		}
+		entry = table.secondary[entry>>entryValueShift][bits>>table.primaryBits]
+	}
+	length := uint(entry & entryLengthMask)
+	if length == 0 && available == needed {
+		return 0, decodeError(stream, fmt.Errorf("%w: %0*b is not a valid huffman code / path", ErrInvalidHuffmanCode,
+			needed, reverseBits(int(bits), int(needed))))
+	}
+	if length == 0 || length > available {
+		return 0, decodeError(stream, ErrUnexpectedEOF)
+	}
+	consumeBits(stream, length)
+	return int(entry >> entryValueShift), nil
+}
+
+// huffmanDecoder decodes the symbols of a huffman code. It uses the lookup tables, unless the tree has been built
+// to decode bit by bit (in the ExplanationMode of the Decoder, as it's easier to follow).
+type huffmanDecoder struct {
+	table *huffmanTable
+	root  *huffmanNode
+}
+
+func newHuffmanDecoder(hRanges []rleRange, bitByBit bool) (*huffmanDecoder, error) {
+	decoder := &huffmanDecoder{}
+	var err error
+	if bitByBit {
+		decoder.root, err = buildHuffmanTree(hRanges)
+	} else {
+		decoder.table, err = buildHuffmanTable(hRanges)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decoder, nil
+}
+
+func decodeSymbol(stream *bitstream, decoder *huffmanDecoder) (int, error) {
+	if decoder.root != nil {
+		code, _, err := getCode(stream, decoder.root)
+		return code, err
+	}
+	return lookupCode(stream, decoder.table)
+}
+
+// huffmanEncoder holds the code of each symbol (tree index), with the bits reversed so they can be written LSB
+// first and still come out MSB first.
+type huffmanEncoder struct {
+	codes []treeNode
+}
+
+func newHuffmanEncoder(hRanges []rleRange) (*huffmanEncoder, error) {
+	tree, _, err := assignHuffmanCodes(hRanges)
+	if err != nil {
+		return nil, err
+	}
+	for i := range tree {
+		tree[i].code = reverseBits(tree[i].code, tree[i].len)
+	}
+	return &huffmanEncoder{codes: tree}, nil
+}
+
+func encodeSymbol(writer *bitWriter, encoder *huffmanEncoder, symbol int) {
+	node := encoder.codes[symbol]
+	if node.len == 0 {
+		panic(fmt.Sprintf("symbol %d is not part of the huffman code", symbol))
+	}
+	writeBitsInv(writer, uint64(node.code), uint(node.len))
+}
+
+/*
+Building the codes (the encoder side): given how often each symbol is used, find the bit lengths giving the
+shortest output. That's what huffman's algorithm does, but deflate limits the code lengths (15 bits for the
+literals and distances, 7 bits for the code lengths alphabet), and huffman's algorithm doesn't care about that.
+
+The package-merge algorithm finds the best lengths under the limit. Think of each symbol as a coin whose value is
+its frequency, and each bit of a code as a coin to spend:
+- start with the list of symbols, sorted by frequency
+- maxBits-1 times: pair up the items of the list (cheapest first) into packages, then merge these packages with the
+  symbols again into a new sorted list
+- pick the 2n-2 cheapest items of the final list; the length of a symbol's code is the number of picked items it
+  is part of
+
+A package has to hold two items of the list below, so a symbol can only be part of maxBits items, which is what
+limits its length. The canonical codes can then be assigned from the lengths, like the decoder does.
+*/
+
+// packageItem is a symbol or a package of items, symbols lists all the symbols it holds
+type packageItem struct {
+	weight  int
+	symbols []int
+}
+
+// huffmanBitLengths returns the bit length of the code of each symbol, 0 for the unused ones (frequency 0)
+func huffmanBitLengths(freqs []int, maxBits int) []int {
+	lengths := make([]int, len(freqs))
+	var leaves []packageItem
+	for symbol, freq := range freqs {
+		if freq > 0 {
+			leaves = append(leaves, packageItem{freq, []int{symbol}})
+		}
+	}
+	if len(leaves) <= 1 {
+		// a code needs at least 1 bit
+		for _, leaf := range leaves {
+			lengths[leaf.symbols[0]] = 1
+		}
+		return lengths
+	}
+	if len(leaves) > 1<<maxBits {
+		panic(fmt.Sprintf("%d symbols don't fit in %d bits", len(leaves), maxBits))
+	}
+	sort.SliceStable(leaves, func(i, j int) bool { return leaves[i].weight < leaves[j].weight })
+
+	list := leaves
+	for level := 1; level < maxBits; level++ {
+		var packages []packageItem
+		for i := 0; i+1 < len(list); i += 2 {
+			symbols := append(append([]int{}, list[i].symbols...), list[i+1].symbols...)
+			packages = append(packages, packageItem{list[i].weight + list[i+1].weight, symbols})
+		}
+		list = mergeItems(leaves, packages)
+	}
+	for _, item := range list[:2*len(leaves)-2] {
+		for _, symbol := range item.symbols {
+			lengths[symbol]++
+		}
+	}
+	return lengths
+}
+
+// mergeItems merges two lists sorted by weight
+func mergeItems(a, b []packageItem) []packageItem {
+	merged := make([]packageItem, 0, len(a)+len(b))
+	for len(a) > 0 && len(b) > 0 {
+		if b[0].weight < a[0].weight {
+			merged, b = append(merged, b[0]), b[1:]
+		} else {
+			merged, a = append(merged, a[0]), a[1:]
+		}
+	}
+	return append(append(merged, a...), b...)
+}
@@ -0,0 +1,198 @@
+package gzip
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildHuffmanTree(t *testing.T) {
+	testCases := []struct {
+		hRanges              []rleRange
+		expectedHuffmanCodes []string
+	}{
+		{
+			[]rleRange{
+				{1, 4},
+				{4, 6},
+				{6, 4},
+				{14, 5},
+				{18, 6},
+				{21, 4},
+				{26, 6},
+			},
+			[]string{
+				"0000",
+				"0001",
+				"101100",
+				"101101",
+				"101110",
+				"0010",
+				"0011",
+				"01110",
+				"01111",
+				"10000",
+				"10001",
+				"10010",
+				"10011",
+				"10100",
+				"10101",
+				"101111",
+				"110000",
+				"110001",
+				"110010",
+				"0100",
+				"0101",
+				"0110",
+				"110011",
+				"110100",
+				"110101",
+				"110110",
+				"110111",
+			},
+		},
+		{
+			[]rleRange{
+				{0, 3},
+				{3, 0},
+				{5, 4},
+				{6, 3},
+				{7, 2},
+				{9, 3},
+				{10, 4},
+				{11, 5},
+				{15, 0},
+				{16, 6},
+				{18, 7},
+			},
+			[]string{
+				// total of 19 codes
+
+				// 0
+				"010",
+				"",
+				"",
+				"",
+				"1100",
+
+				// 5
+				"1101",
+				"011",
+				"00",
+				"100",
+				"101",
+
+				// 10
+				"1110",
+				"11110",
+				"",
+				"",
+				"",
+
+				// 15
+				"",
+				"111110",
+				"1111110",
+				"1111111",
+			},
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i+1), func(t *testing.T) {
+			root, err := buildHuffmanTree(tc.hRanges)
+			assert.NoError(t, err)
+			codeTable := make([]string, tc.hRanges[len(tc.hRanges)-1].end+1) // symbol 0 is valid
+			traverseHuffmanTree(root, "", codeTable)
+			for i, v := range codeTable {
+				assert.Equal(t, tc.expectedHuffmanCodes[i], v)
+			}
+		})
+	}
+
+}
+
+func TestBuildHuffmanTreeOverSubscribed(t *testing.T) {
+	// three 1-bit codes
+	_, err := buildHuffmanTree([]rleRange{
+		{2, 1},
+	})
+	assert.ErrorIs(t, err, ErrInvalidHuffmanTree)
+}
+
+func TestBuildHuffmanTable(t *testing.T) {
+	testCases := [][]rleRange{
+		{
+			{0, 3},
+			{3, 0},
+			{5, 4},
+			{6, 3},
+			{7, 2},
+			{9, 3},
+			{10, 4},
+			{11, 5},
+			{15, 0},
+			{16, 6},
+			{18, 7},
+		},
+		// codes longer than primaryBits go through the secondary tables
+		{
+			{0, 1}, {1, 2}, {2, 3}, {3, 4}, {4, 5}, {5, 6}, {6, 7},
+			{7, 8}, {8, 9}, {9, 10}, {10, 11}, {11, 12}, {12, 13}, {14, 14},
+		},
+	}
+	for i, hRanges := range testCases {
+		t.Run(fmt.Sprintf("Test %d", i+1), func(t *testing.T) {
+			root, err := buildHuffmanTree(hRanges)
+			assert.NoError(t, err)
+			codeTable := make([]string, hRanges[len(hRanges)-1].end+1)
+			traverseHuffmanTree(root, "", codeTable)
+
+			table, err := buildHuffmanTable(hRanges)
+			assert.NoError(t, err)
+			for symbol, code := range codeTable {
+				if code == "" {
+					continue
+				}
+				// the code, followed by another code to make sure only the bits of the first one are consumed
+				stream := &bitstream{source: bytes.NewReader(helperBitStringToBytes(code + codeTable[symbol]))}
+				for j := 0; j < 2; j++ {
+					decoded, err := lookupCode(stream, table)
+					assert.NoError(t, err)
+					assert.Equal(t, symbol, decoded, code)
+				}
+			}
+		})
+	}
+}
+
+func TestHuffmanBitLengths(t *testing.T) {
+	// without a limit, this is the classic huffman code
+	assert.Equal(t, []int{1, 2, 3, 3, 0}, huffmanBitLengths([]int{10, 5, 2, 1, 0}, 15))
+	assert.Equal(t, []int{0, 1, 0}, huffmanBitLengths([]int{0, 3, 0}, 15))
+
+	// fibonacci frequencies make the deepest possible huffman tree, the limit has to cut it
+	freqs := []int{1, 1, 2, 3, 5, 8, 13, 21, 34, 55, 89, 144}
+	assert.Equal(t, 11, maxOf(huffmanBitLengths(freqs, 15)))
+	lengths := huffmanBitLengths(freqs, 7)
+	assert.Equal(t, 7, maxOf(lengths))
+	// the code is complete: sum(2^-length) == 1
+	kraft := 0
+	for _, length := range lengths {
+		kraft += 1 << (7 - length)
+	}
+	assert.Equal(t, 1<<7, kraft)
+	_, err := newHuffmanEncoder(runLengthEncoding(lengths))
+	assert.NoError(t, err)
+}
+
+func maxOf(values []int) int {
+	max := 0
+	for _, v := range values {
+		if v > max {
+			max = v
+		}
+	}
+	return max
+}
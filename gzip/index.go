@@ -0,0 +1,202 @@
+package gzip
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+)
+
+/*
+Random access (what zlib's zran example does):
+
+A deflate stream can't be decoded from the middle: the back-pointers refer to the previous 32 KiB of output, and
+the huffman codes are described at the start of each block. But at the boundary between two blocks, all the
+inflater needs to carry on is the position of the next block header and the last 32 KiB of output.
+
+So an Index is built with one pass over the whole file, recording such an access point every span bytes of output.
+Reading at an offset then starts from the closest access point before it, instead of from the start of the file.
+*/
+
+var indexMagic = [4]byte{'G', 'Z', 'I', 'X'}
+
+// AccessPoint is a place where the inflation can be resumed
+type AccessPoint struct {
+	CompressedBits int64  // position of the block header in the gzip file, in bits
+	Offset         int64  // uncompressed offset of the start of the block
+	Window         []byte // the (up to) 32 KiB of uncompressed data before Offset
+}
+
+// Index holds the access points of a gzip file, in order
+type Index struct {
+	Span   int64 // minimum number of uncompressed bytes between two access points
+	Size   int64 // uncompressed size of the whole file
+	Points []AccessPoint
+}
+
+// BuildIndex reads the whole gzip file from r, and records an access point at the first block boundary after every
+// span bytes of uncompressed data. The blocks written by gzip are usually a few dozen KiB of uncompressed data, so
+// span is only approximate.
+func BuildIndex(r io.Reader, span int64) (*Index, error) {
+	if span <= 0 {
+		return nil, fmt.Errorf("gzip: invalid span %d", span)
+	}
+	z, err := NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	index := &Index{Span: span}
+	// the first access point is the start of the first block, there is no history yet
+	index.Points = append(index.Points, AccessPoint{CompressedBits: bitOffset(z.stream)})
+	z.blockEnd = func(f *inflater) {
+		offset := z.memberOffset + f.pos
+		if offset-index.Points[len(index.Points)-1].Offset >= span {
+			index.Points = append(index.Points, AccessPoint{
+				CompressedBits: bitOffset(f.stream),
+				Offset:         offset,
+				Window:         f.window.snapshot(),
+			})
+		}
+	}
+	z.inflater.blockEnd = z.blockEnd
+	if index.Size, err = io.Copy(io.Discard, z); err != nil {
+		return nil, err
+	}
+	return index, nil
+}
+
+// WriteTo serializes the index, compressed with gzip (the windows are mostly text, so they compress well)
+func (index *Index) WriteTo(w io.Writer) (int64, error) {
+	counter := &countingWriter{w: w}
+	z := NewWriter(counter)
+	header := []interface{}{indexMagic, index.Span, index.Size, uint32(len(index.Points))}
+	for _, value := range header {
+		if err := binary.Write(z, binary.LittleEndian, value); err != nil {
+			return counter.n, err
+		}
+	}
+	for _, point := range index.Points {
+		for _, value := range []interface{}{point.CompressedBits, point.Offset, uint32(len(point.Window)), point.Window} {
+			if err := binary.Write(z, binary.LittleEndian, value); err != nil {
+				return counter.n, err
+			}
+		}
+	}
+	err := z.Close()
+	return counter.n, err
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// ReadIndex reads an index serialized by WriteTo
+func ReadIndex(r io.Reader) (*Index, error) {
+	z, err := NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	var magic [4]byte
+	var count uint32
+	index := &Index{}
+	for _, value := range []interface{}{&magic, &index.Span, &index.Size, &count} {
+		if err := binary.Read(z, binary.LittleEndian, value); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidIndex, err)
+		}
+	}
+	if magic != indexMagic {
+		return nil, fmt.Errorf("%w: bad magic %q", ErrInvalidIndex, magic[:])
+	}
+	for i := uint32(0); i < count; i++ {
+		var point AccessPoint
+		var windowLength uint32
+		for _, value := range []interface{}{&point.CompressedBits, &point.Offset, &windowLength} {
+			if err := binary.Read(z, binary.LittleEndian, value); err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrInvalidIndex, err)
+			}
+		}
+		if windowLength > windowSize {
+			return nil, fmt.Errorf("%w: window of %d bytes", ErrInvalidIndex, windowLength)
+		}
+		point.Window = make([]byte, windowLength)
+		if _, err := io.ReadFull(z, point.Window); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidIndex, err)
+		}
+		// the index may come from anywhere, ReadAt relies on these
+		if point.CompressedBits < 0 {
+			return nil, fmt.Errorf("%w: access point at bit %d", ErrInvalidIndex, point.CompressedBits)
+		}
+		if i == 0 && point.Offset != 0 {
+			return nil, fmt.Errorf("%w: first access point at offset %d instead of 0", ErrInvalidIndex, point.Offset)
+		}
+		if i > 0 && point.Offset < index.Points[i-1].Offset {
+			return nil, fmt.Errorf("%w: access points out of order", ErrInvalidIndex)
+		}
+		index.Points = append(index.Points, point)
+	}
+	if len(index.Points) == 0 {
+		return nil, fmt.Errorf("%w: no access point", ErrInvalidIndex)
+	}
+	if last := index.Points[len(index.Points)-1]; index.Size < last.Offset {
+		return nil, fmt.Errorf("%w: size %d before the last access point (%d)", ErrInvalidIndex, index.Size, last.Offset)
+	}
+	return index, nil
+}
+
+// IndexedReader reads the uncompressed data of a gzip file at any offset, using its Index
+type IndexedReader struct {
+	file  io.ReaderAt
+	index *Index
+}
+
+func NewIndexedReader(file io.ReaderAt, index *Index) *IndexedReader {
+	return &IndexedReader{file: file, index: index}
+}
+
+// ReadAt reads len(p) bytes of uncompressed data starting at off, inflating from the closest access point before
+// off. Like io.ReaderAt, it returns an error (io.EOF at the end of the data) if less than len(p) bytes are read.
+func (r *IndexedReader) ReadAt(p []byte, off int64) (int, error) {
+	if off < 0 {
+		return 0, errors.New("gzip: negative offset")
+	}
+	if off >= r.index.Size {
+		return 0, io.EOF
+	}
+	points := r.index.Points
+	i := sort.Search(len(points), func(i int) bool { return points[i].Offset > off }) - 1
+	z, err := resumeReader(r.file, points[i])
+	if err != nil {
+		return 0, err
+	}
+	if _, err := io.CopyN(io.Discard, z, off-points[i].Offset); err != nil {
+		return 0, err
+	}
+	n, err := io.ReadFull(z, p)
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF // the data ended before p was full
+	}
+	return n, err
+}
+
+// resumeReader creates a Reader starting at an access point, in the middle of a member
+func resumeReader(file io.ReaderAt, point AccessPoint) (*Reader, error) {
+	offset := point.CompressedBits / 8
+	z := newReader(io.NewSectionReader(file, offset, math.MaxInt64-offset), &Decoder{})
+	z.stream.offset = offset
+	if _, err := readBitsInv(z.stream, int(point.CompressedBits%8)); err != nil {
+		return nil, err
+	}
+	z.inflater = newInflater(z.stream, z.decoder)
+	z.skipVerify = true
+	z.inflater.window.preset(point.Window)
+	return z, nil
+}
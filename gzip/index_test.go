@@ -0,0 +1,112 @@
+package gzip
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// indexTestFile returns a gzip file of two members, with many blocks
+func indexTestFile(t *testing.T) (data []byte, compressed []byte) {
+	first := bytes.Repeat(testInputs()["feynman.txt"], 8)
+	second := testInputs()["random"]
+	var out bytes.Buffer
+	for _, member := range [][]byte{first, second} {
+		writer, err := NewWriterLevel(&out, BestSpeed)
+		assert.NoError(t, err)
+		_, err = writer.Write(member)
+		assert.NoError(t, err)
+		assert.NoError(t, writer.Close())
+	}
+	return append(append([]byte{}, first...), second...), out.Bytes()
+}
+
+func TestIndexReadAt(t *testing.T) {
+	data, compressed := indexTestFile(t)
+	index, err := BuildIndex(bytes.NewReader(compressed), 20000)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(data)), index.Size)
+	assert.Greater(t, len(index.Points), 5)
+	for i, point := range index.Points {
+		if i > 0 {
+			assert.GreaterOrEqual(t, point.Offset-index.Points[i-1].Offset, index.Span)
+		}
+		assert.True(t, bytes.Equal(data[point.Offset-int64(len(point.Window)):point.Offset], point.Window))
+	}
+
+	reader := NewIndexedReader(bytes.NewReader(compressed), index)
+	random := rand.New(rand.NewSource(1))
+	for i := 0; i < 50; i++ {
+		off := random.Int63n(int64(len(data)))
+		p := make([]byte, random.Intn(100000))
+		n, err := reader.ReadAt(p, off)
+		expected := data[off:]
+		if len(expected) > len(p) {
+			expected = expected[:len(p)]
+			assert.NoError(t, err)
+		} else if len(expected) < len(p) {
+			assert.Equal(t, io.EOF, err)
+		}
+		assert.Equal(t, len(expected), n)
+		assert.True(t, bytes.Equal(expected, p[:n]), "reading %d bytes at %d", len(p), off)
+	}
+	_, err = reader.ReadAt(make([]byte, 1), int64(len(data)))
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestIndexSerialization(t *testing.T) {
+	_, compressed := indexTestFile(t)
+	index, err := BuildIndex(bytes.NewReader(compressed), 50000)
+	assert.NoError(t, err)
+
+	var serialized bytes.Buffer
+	n, err := index.WriteTo(&serialized)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(serialized.Len()), n)
+
+	read, err := ReadIndex(bytes.NewReader(serialized.Bytes()))
+	assert.NoError(t, err)
+	assert.Equal(t, index.Span, read.Span)
+	assert.Equal(t, index.Size, read.Size)
+	assert.Equal(t, len(index.Points), len(read.Points))
+	for i := range index.Points {
+		assert.Equal(t, index.Points[i].CompressedBits, read.Points[i].CompressedBits)
+		assert.Equal(t, index.Points[i].Offset, read.Points[i].Offset)
+		assert.Equal(t, len(index.Points[i].Window), len(read.Points[i].Window))
+	}
+
+	var notAnIndex bytes.Buffer
+	writer := NewWriter(&notAnIndex)
+	writer.Write([]byte("not an index"))
+	writer.Close()
+	_, err = ReadIndex(&notAnIndex)
+	assert.ErrorIs(t, err, ErrInvalidIndex)
+}
+
+func TestReadIndexInvalid(t *testing.T) {
+	_, compressed := indexTestFile(t)
+	testCases := []struct {
+		name   string
+		modify func(index *Index)
+	}{
+		{"first point not at 0", func(index *Index) { index.Points[0].Offset = 10 }},
+		{"negative position", func(index *Index) { index.Points[1].CompressedBits = -8 }},
+		{"out of order", func(index *Index) { index.Points[1].Offset = index.Points[2].Offset + 1 }},
+		{"size before the last point", func(index *Index) { index.Size = index.Points[len(index.Points)-1].Offset - 1 }},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			index, err := BuildIndex(bytes.NewReader(compressed), 20000)
+			assert.NoError(t, err)
+			tc.modify(index)
+			var serialized bytes.Buffer
+			_, err = index.WriteTo(&serialized)
+			assert.NoError(t, err)
+			_, err = ReadIndex(&serialized)
+			assert.ErrorIs(t, err, ErrInvalidIndex)
+		})
+	}
+}
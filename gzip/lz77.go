@@ -0,0 +1,153 @@
+package gzip
+
+const (
+	minMatch = 3   // shorter back-pointers can't be encoded (and wouldn't save anything)
+	maxMatch = 258 // longest back-pointer length
+
+	hashBits = 15
+	hashSize = 1 << hashBits
+)
+
+// token is what the LZ77 step turns the input into: either a literal byte, or a back-pointer
+type token struct {
+	length  uint16 // 0 for a literal
+	dist    uint16
+	literal byte
+}
+
+func literalToken(literal byte) token {
+	return token{literal: literal}
+}
+
+func matchToken(length, dist int) token {
+	return token{length: uint16(length), dist: uint16(dist)}
+}
+
+/*
+Finding back-pointers with hash chains:
+
+Every position is hashed with the 3 bytes starting there (the shortest possible match). head holds, for each hash,
+the latest position having that hash, and prev links every position to the previous position with the same hash.
+So walking the chain from head[hash] visits all the earlier positions that may start a match, from the closest to
+the furthest, and we keep the longest match found.
+
+The chains can get very long on repetitive data, hence maxChain which limits how many positions are visited, and
+niceLength: once a match is that long, it's good enough.
+
+Positions are indexes in the buffer of the deflater (plus 1, so 0 means "no position"). When the buffer slides
+(always by windowSize, so prev stays indexed the same way), the positions are shifted down along with it.
+*/
+type matcher struct {
+	head       []int32 // hashSize
+	prev       []int32 // windowSize, indexed by position % windowSize
+	maxChain   int
+	niceLength int
+}
+
+func newMatcher(maxChain int, niceLength int) matcher {
+	return matcher{
+		head:       make([]int32, hashSize),
+		prev:       make([]int32, windowSize),
+		maxChain:   maxChain,
+		niceLength: niceLength,
+	}
+}
+
+func hash3(b []byte) uint32 {
+	return (uint32(b[0])<<16 | uint32(b[1])<<8 | uint32(b[2])) * 0x9E3779B1 >> (32 - hashBits)
+}
+
+// insertHash adds pos to the chain of the 3 bytes starting there
+func insertHash(m *matcher, buf []byte, pos int) {
+	if pos+minMatch > len(buf) {
+		return
+	}
+	h := hash3(buf[pos:])
+	m.prev[pos%windowSize] = m.head[h]
+	m.head[h] = int32(pos + 1)
+}
+
+// findMatch returns the longest match (up to maxLength) for the bytes at pos, among the earlier positions with
+// the same hash. The length is 0 if there is no match of at least minMatch bytes.
+func findMatch(m *matcher, buf []byte, pos int, maxLength int) (length int, dist int) {
+	var candidates [8]matchCandidate
+	matches := findMatches(m, buf, pos, maxLength, candidates[:0])
+	if len(matches) == 0 {
+		return 0, 0
+	}
+	longest := matches[len(matches)-1]
+	return longest.length, longest.dist
+}
+
+// matchCandidate is a match found by findMatches: any length from the length of the previous candidate + 1 up to
+// length can be encoded with dist
+type matchCandidate struct {
+	length int
+	dist   int
+}
+
+// findMatches walks the chain of pos, and appends to candidates every match longer than the ones found before.
+// As the chain goes from the closest positions to the furthest, each candidate is the closest match of its length,
+// which has the cheapest distance code.
+func findMatches(m *matcher, buf []byte, pos int, maxLength int, candidates []matchCandidate) []matchCandidate {
+	if pos+maxLength > len(buf) {
+		maxLength = len(buf) - pos
+	}
+	if maxLength < minMatch {
+		return candidates
+	}
+	length := minMatch - 1
+	candidate := int(m.head[hash3(buf[pos:])]) - 1
+	for chain := 0; chain < m.maxChain && candidate >= 0 && candidate < pos && pos-candidate <= windowSize; chain++ {
+		// check the byte that would make the match longer than the best one first, it is the most likely to differ
+		if buf[candidate+length] == buf[pos+length] {
+			l := 0
+			for l < maxLength && buf[candidate+l] == buf[pos+l] {
+				l++
+			}
+			if l > length {
+				length = l
+				candidates = append(candidates, matchCandidate{l, pos - candidate})
+				if l == maxLength || l >= m.niceLength {
+					break
+				}
+			}
+		}
+		next := int(m.prev[candidate%windowSize]) - 1
+		if next >= candidate {
+			break // the slot has been reused by a later position, the rest of the chain is gone
+		}
+		candidate = next
+	}
+	return candidates
+}
+
+// findRun returns the length of the run of the byte before pos starting at pos (a match at distance 1), 0 if it
+// is shorter than minMatch
+func findRun(buf []byte, pos int, maxLength int) int {
+	if pos == 0 {
+		return 0
+	}
+	length := 0
+	for length < maxLength && pos+length < len(buf) && buf[pos+length] == buf[pos-1] {
+		length++
+	}
+	if length < minMatch {
+		return 0
+	}
+	return length
+}
+
+// slideMatcher shifts all the positions down by windowSize, forgetting the ones that fall off the buffer
+func slideMatcher(m *matcher) {
+	delta := windowSize
+	for _, positions := range [][]int32{m.head, m.prev} {
+		for i, p := range positions {
+			if int(p) > delta {
+				positions[i] = p - int32(delta)
+			} else {
+				positions[i] = 0
+			}
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package gzip
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindMatch(t *testing.T) {
+	buf := []byte("abcdeabcdxabcdeab")
+	m := newMatcher(128, maxMatch)
+	for pos := 0; pos < 10; pos++ {
+		insertHash(&m, buf, pos)
+	}
+	// "abcdeab" at 10: "abcd" at 5 is closer, but "abcdeab" at 0 is longer
+	length, dist := findMatch(&m, buf, 10, maxMatch)
+	assert.Equal(t, 7, length)
+	assert.Equal(t, 10, dist)
+
+	length, dist = findMatch(&m, buf, 10, 4)
+	assert.Equal(t, 4, length)
+	assert.Equal(t, 5, dist)
+
+	// "xab" has never been seen
+	length, _ = findMatch(&m, buf, 9, maxMatch)
+	assert.Equal(t, 0, length)
+}
+
+func TestSlideMatcher(t *testing.T) {
+	buf := make([]byte, 2*windowSize)
+	copy(buf[windowSize+10:], "abcX")
+	copy(buf[windowSize+100:], "abcY")
+	m := newMatcher(128, maxMatch)
+	insertHash(&m, buf, windowSize+10)
+	slideMatcher(&m)
+
+	length, dist := findMatch(&m, buf[windowSize:], 100, maxMatch)
+	assert.Equal(t, 3, length)
+	assert.Equal(t, 90, dist)
+}
@@ -0,0 +1,30 @@
+package gzip
+
+import (
+	"io"
+)
+
+// Member describes one member of a gzip file, as found by ListMembers
+type Member struct {
+	Offset         int64 // offset of the member in the gzip file
+	CompressedSize int64 // size of the whole member in the file: header, deflate stream and trailer
+	Size           int64 // size of the inflated data (the trailer only has it modulo 2^32)
+	Header         GzipMetaData
+	Trailer        GzipTrailer
+}
+
+// ListMembers reads the whole gzip file from r and describes each of its members. The data has to be inflated to
+// find where a member ends, but it is thrown away as it goes, so any size of file can be listed.
+// If the file is corrupted, the members before the error are returned along with it.
+func ListMembers(r io.Reader) ([]Member, error) {
+	z, err := NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	var members []Member
+	z.memberEnd = func(m Member) {
+		members = append(members, m)
+	}
+	_, err = io.Copy(io.Discard, z)
+	return members, err
+}
@@ -0,0 +1,48 @@
+package gzip
+
+import (
+	"bytes"
+	"errors"
+	"hash/crc32"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListMembers(t *testing.T) {
+	inputs := [][]byte{testInputs()["feynman.txt"], {}, testInputs()["random"]}
+	var out bytes.Buffer
+	var offsets []int64
+	for i, input := range inputs {
+		offsets = append(offsets, int64(out.Len()))
+		writer := NewWriter(&out)
+		assert.NoError(t, writer.SetHeader(Header{Name: string(rune('a' + i))}))
+		_, err := writer.Write(input)
+		assert.NoError(t, err)
+		assert.NoError(t, writer.Close())
+	}
+	offsets = append(offsets, int64(out.Len()))
+
+	members, err := ListMembers(bytes.NewReader(out.Bytes()))
+	assert.NoError(t, err)
+	assert.Len(t, members, len(inputs))
+	for i, member := range members {
+		assert.Equal(t, offsets[i], member.Offset)
+		assert.Equal(t, offsets[i+1]-offsets[i], member.CompressedSize)
+		assert.Equal(t, int64(len(inputs[i])), member.Size)
+		assert.Equal(t, []byte{byte('a' + i)}, member.Header.Fname)
+		assert.Equal(t, crc32.ChecksumIEEE(inputs[i]), member.Trailer.Crc32)
+		assert.Equal(t, uint32(len(inputs[i])), member.Trailer.Isize)
+	}
+
+	// the members before a corrupted one are still listed
+	corrupted := append([]byte{}, out.Bytes()...)
+	corrupted[offsets[2]+20] ^= 0xff
+	members, err = ListMembers(bytes.NewReader(corrupted))
+	assert.Error(t, err)
+	assert.Len(t, members, 2)
+
+	var decodeErr *DecodeError
+	assert.True(t, errors.As(err, &decodeErr))
+	assert.GreaterOrEqual(t, decodeErr.Offset, offsets[2])
+}
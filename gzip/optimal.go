@@ -0,0 +1,209 @@
+package gzip
+
+import "math"
+
+const (
+	maxOptimalBlock   = windowSize // input parsed at once by optimalParse
+	optimalIterations = 10
+	splitCandidates   = 16   // split points tried when splitting a block
+	minSplitTokens    = 1024 // blocks smaller than that aren't split any further
+	maxSplitDepth     = 4    // at most 2^4 blocks out of one
+)
+
+/*
+Optimal parsing (what Zopfli does):
+
+The lazy matching of the other levels takes the longest match it can find, which isn't always the best choice: a
+shorter match may let the next match start at a better place, and a literal may be cheaper than a short match with
+a long distance. What "cheaper" means depends on the huffman codes of the block, which depend on the tokens...
+
+So the parsing is done in rounds:
+- the input is seen as a graph: each position is a node, a literal is an edge to the next position, and a match of
+  length L is an edge to L positions further. Each edge costs the number of bits its codes take.
+- the cheapest path from the first to the last position gives the tokens (a shortest path, easy to find as the
+  edges only go forward)
+- the frequencies of these tokens give new costs (the bits a symbol takes with the huffman code built from them),
+  and the path is searched again with them. The best tokens of all the rounds are kept.
+
+The first round uses the costs of the fixed codes. Its tokens are also used to decide where to split the input into
+separate blocks, as the codes that suit the start of the input may not suit its end.
+*/
+
+// costModel gives the number of bits each symbol is expected to take
+type costModel struct {
+	literals  []float64 // literal/length codes, without the extra bits
+	distances []float64 // distance codes, without the extra bits
+	lengths   []float64 // length code and extra bits of each length, to avoid calling lengthSymbol over and over
+}
+
+func newCostModel(literals, distances []float64) costModel {
+	model := costModel{literals: literals, distances: distances, lengths: make([]float64, maxMatch+1)}
+	for length := minMatch; length <= maxMatch; length++ {
+		code, extraBits, _ := lengthSymbol(length)
+		model.lengths[length] = literals[code] + float64(extraBits)
+	}
+	return model
+}
+
+// fixedCostModel uses the lengths of the fixed codes
+func fixedCostModel() costModel {
+	literals := make([]float64, literalCodes)
+	for symbol := range literals {
+		switch {
+		case symbol < 144:
+			literals[symbol] = 8
+		case symbol < 256:
+			literals[symbol] = 9
+		case symbol < 280:
+			literals[symbol] = 7
+		default:
+			literals[symbol] = 8
+		}
+	}
+	distances := make([]float64, distanceCodes)
+	for symbol := range distances {
+		distances[symbol] = 5
+	}
+	return newCostModel(literals, distances)
+}
+
+// statisticsCostModel uses the frequencies of the symbols of tokens: a symbol used n times out of total costs
+// log2(total/n) bits (its entropy), which is about the length of its huffman code
+func statisticsCostModel(tokens []token) costModel {
+	literalFreqs, distanceFreqs := tokenFrequencies(tokens)
+	return newCostModel(entropyCosts(literalFreqs), entropyCosts(distanceFreqs))
+}
+
+func entropyCosts(freqs []int) []float64 {
+	total := 0
+	for _, freq := range freqs {
+		total += freq
+	}
+	costs := make([]float64, len(freqs))
+	log2Total := math.Log2(float64(total + 1))
+	for symbol, freq := range freqs {
+		if freq == 0 {
+			costs[symbol] = log2Total + 1 // unused so far, it would need a long code
+		} else {
+			costs[symbol] = log2Total - math.Log2(float64(freq))
+		}
+	}
+	return costs
+}
+
+func (model costModel) distanceCost(dist int) float64 {
+	code, extraBits, _ := distanceSymbol(dist)
+	return model.distances[code] + float64(extraBits)
+}
+
+// optimalParse returns the cheapest tokens for buf[start:end] according to model. candidates holds the matches
+// found at each position of buf[start:end].
+func optimalParse(buf []byte, start, end int, candidates [][]matchCandidate, model costModel) []token {
+	n := end - start
+	cost := make([]float64, n+1) // cheapest cost to get to each position
+	step := make([]matchCandidate, n+1)
+	for i := 1; i <= n; i++ {
+		cost[i] = math.Inf(1)
+	}
+	for i := 0; i < n; i++ {
+		if c := cost[i] + model.literals[buf[start+i]]; c < cost[i+1] {
+			cost[i+1], step[i+1] = c, matchCandidate{1, 0}
+		}
+		length := minMatch
+		for _, match := range candidates[i] {
+			distCost := model.distanceCost(match.dist)
+			for ; length <= match.length && i+length <= n; length++ {
+				if c := cost[i] + model.lengths[length] + distCost; c < cost[i+length] {
+					cost[i+length], step[i+length] = c, matchCandidate{length, match.dist}
+				}
+			}
+		}
+	}
+
+	// walk the path back from the end
+	var tokens []token
+	for i := n; i > 0; i -= step[i].length {
+		if step[i].length == 1 {
+			tokens = append(tokens, literalToken(buf[start+i-1]))
+		} else {
+			tokens = append(tokens, matchToken(step[i].length, step[i].dist))
+		}
+	}
+	for i, j := 0, len(tokens)-1; i < j; i, j = i+1, j-1 {
+		tokens[i], tokens[j] = tokens[j], tokens[i]
+	}
+	return tokens
+}
+
+// writeOptimalBlocks parses buf[start:end] with optimalParse, splits it into blocks and writes them
+func (d *deflater) writeOptimalBlocks(start, end int, final bool) {
+	candidates := make([][]matchCandidate, end-start)
+	for pos := start; pos < end; pos++ {
+		for ; d.hashed < pos; d.hashed++ {
+			insertHash(&d.matcher, d.buf, d.hashed)
+		}
+		maxLength := maxMatch
+		if end-pos < maxLength {
+			maxLength = end - pos // the matches can't go past the block
+		}
+		candidates[pos-start] = findMatches(&d.matcher, d.buf, pos, maxLength, nil)
+	}
+
+	tokens := optimalParse(d.buf, start, end, candidates, fixedCostModel())
+	blockSizes := d.splitBlock(tokens, d.buf[start:end], 0)
+	for i, size := range blockSizes {
+		blockTokens := d.optimizeBlock(start, start+size, candidates[:size])
+		d.writeTokenBlock(blockTokens, d.buf[start:start+size], final && i == len(blockSizes)-1)
+		start += size
+		candidates = candidates[size:]
+	}
+}
+
+// optimizeBlock runs the rounds of optimalParse on buf[start:end], and returns the tokens of the smallest block
+func (d *deflater) optimizeBlock(start, end int, candidates [][]matchCandidate) []token {
+	raw := d.buf[start:end]
+	model := fixedCostModel()
+	var best []token
+	bestBits := math.MaxInt
+	for round := 0; round < optimalIterations; round++ {
+		tokens := optimalParse(d.buf, start, end, candidates, model)
+		_, bits, _ := d.blockType(tokens, raw)
+		if bits < bestBits {
+			best, bestBits = tokens, bits
+		}
+		model = statisticsCostModel(tokens)
+	}
+	return best
+}
+
+// splitBlock finds where splitting the tokens (the input of which is raw) into separate blocks makes the output
+// smaller, trying a few split points and then splitting the halves again. It returns the size of the input of each
+// block.
+func (d *deflater) splitBlock(tokens []token, raw []byte, depth int) []int {
+	if depth == maxSplitDepth || len(tokens) < 2*minSplitTokens {
+		return []int{len(raw)}
+	}
+	offsets := make([]int, len(tokens)+1) // offset in raw of each token
+	for i, t := range tokens {
+		offsets[i+1] = offsets[i] + 1
+		if t.length > 0 {
+			offsets[i+1] = offsets[i] + int(t.length)
+		}
+	}
+
+	_, bestBits, _ := d.blockType(tokens, raw)
+	bestSplit := 0
+	for k := 1; k < splitCandidates; k++ {
+		split := len(tokens) * k / splitCandidates
+		_, left, _ := d.blockType(tokens[:split], raw[:offsets[split]])
+		_, right, _ := d.blockType(tokens[split:], raw[offsets[split]:])
+		if bits := left + right + 3; bits < bestBits {
+			bestBits, bestSplit = bits, split
+		}
+	}
+	if bestSplit == 0 {
+		return []int{len(raw)}
+	}
+	sizes := d.splitBlock(tokens[:bestSplit], raw[:offsets[bestSplit]], depth+1)
+	return append(sizes, d.splitBlock(tokens[bestSplit:], raw[offsets[bestSplit]:], depth+1)...)
+}
@@ -0,0 +1,75 @@
+package gzip
+
+import (
+	"bytes"
+	stdgzip "compress/gzip"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOptimalParse(t *testing.T) {
+	buf := []byte("abcdefabcdXabcdef")
+	m := newMatcher(4096, maxMatch)
+	var candidates [][]matchCandidate
+	for pos := range buf {
+		candidates = append(candidates, findMatches(&m, buf, pos, len(buf)-pos, nil))
+		insertHash(&m, buf, pos)
+	}
+	assert.Equal(t, []matchCandidate{{4, 5}, {6, 11}}, candidates[11])
+
+	tokens := optimalParse(buf, 0, len(buf), candidates, fixedCostModel())
+	var out []byte
+	for _, tok := range tokens {
+		if tok.length == 0 {
+			out = append(out, tok.literal)
+			continue
+		}
+		for i := 0; i < int(tok.length); i++ {
+			out = append(out, out[len(out)-int(tok.dist)])
+		}
+	}
+	assert.Equal(t, buf, out)
+	// "abcdef" at the end is cheaper as a single match than "abcd" + "ef"
+	assert.Equal(t, matchToken(6, 11), tokens[len(tokens)-1])
+}
+
+func TestOptimalCompression(t *testing.T) {
+	for name, data := range testInputs() {
+		t.Run(name, func(t *testing.T) {
+			var compressed bytes.Buffer
+			writer, err := NewWriterLevel(&compressed, OptimalCompression)
+			assert.NoError(t, err)
+			_, err = writer.Write(data)
+			assert.NoError(t, err)
+			assert.NoError(t, writer.Close())
+
+			stdReader, err := stdgzip.NewReader(bytes.NewReader(compressed.Bytes()))
+			assert.NoError(t, err)
+			out, err := io.ReadAll(stdReader)
+			assert.NoError(t, err)
+			assert.True(t, bytes.Equal(data, out))
+
+			out, err = readGzipFile(bytes.NewReader(compressed.Bytes()))
+			assert.NoError(t, err)
+			assert.True(t, bytes.Equal(data, out))
+		})
+	}
+
+	data, err := os.ReadFile("../attachment/feynman.txt")
+	if err != nil {
+		panic(err)
+	}
+	var best, optimal bytes.Buffer
+	for level, out := range map[int]*bytes.Buffer{BestCompression: &best, OptimalCompression: &optimal} {
+		writer, err := NewWriterLevel(out, level)
+		assert.NoError(t, err)
+		_, err = writer.Write(data)
+		assert.NoError(t, err)
+		assert.NoError(t, writer.Close())
+	}
+	t.Logf("level 9: %d bytes, optimal: %d bytes", best.Len(), optimal.Len())
+	assert.Less(t, optimal.Len(), best.Len())
+}
@@ -0,0 +1,150 @@
+package gzip
+
+import (
+	"bytes"
+	"fmt"
+	"hash/crc32"
+)
+
+const defaultChunkSize = 128 << 10
+
+/*
+Parallel compression (what pigz does):
+
+The input is split into chunks, each compressed by its own goroutine. To keep the chunks in a single deflate
+stream (and a single gzip member):
+- each chunk is primed with the last 32 KiB of the previous chunk as a preset dictionary, so the back-pointers can
+  still reach across chunks and the compression ratio barely changes
+- each chunk but the last one ends with an empty stored block (a sync flush), so its output stops at a byte boundary
+  and the outputs can simply be concatenated
+- the CRC-32 of each chunk is computed by its goroutine too, and they are combined with crc32Combine
+
+The compressed chunks are written out in order, while up to workers chunks are being compressed.
+*/
+
+// chunkResult is the output of the goroutine compressing a chunk
+type chunkResult struct {
+	compressed []byte
+	checksum   uint32
+	length     int
+	err        error
+}
+
+// parallelDeflater has the same role as the deflater, it splits the input and hands it over to goroutines
+type parallelDeflater struct {
+	writer    *bitWriter
+	level     int
+	strategy  Strategy
+	chunkSize int
+	workers   int
+
+	chunk    []byte // the chunk being filled
+	dict     []byte // the end of the previous chunk
+	pending  []chan chunkResult
+	checksum uint32 // CRC-32 of the chunks written out so far
+}
+
+func newParallelDeflater(writer *bitWriter, level int, strategy Strategy, chunkSize, workers int) (*parallelDeflater, error) {
+	if chunkSize <= 0 || workers <= 0 {
+		return nil, fmt.Errorf("gzip: invalid concurrency, chunks of %d bytes with %d workers", chunkSize, workers)
+	}
+	// check the level and strategy right away, rather than in the goroutines
+	if _, err := newDeflater(writer, level, strategy); err != nil {
+		return nil, err
+	}
+	return &parallelDeflater{
+		writer:    writer,
+		level:     level,
+		strategy:  strategy,
+		chunkSize: chunkSize,
+		workers:   workers,
+	}, nil
+}
+
+func (d *parallelDeflater) write(p []byte) error {
+	for len(p) > 0 {
+		if d.chunk == nil {
+			d.chunk = make([]byte, 0, d.chunkSize)
+		}
+		n := copy(d.chunk[len(d.chunk):cap(d.chunk)], p)
+		d.chunk = d.chunk[:len(d.chunk)+n]
+		p = p[n:]
+		if len(d.chunk) == cap(d.chunk) {
+			if err := d.dispatch(false); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (d *parallelDeflater) close() error {
+	if err := d.dispatch(true); err != nil {
+		return err
+	}
+	for len(d.pending) > 0 {
+		if err := d.collect(); err != nil {
+			return err
+		}
+	}
+	return flushWriter(d.writer)
+}
+
+// dispatch starts compressing the current chunk, after waiting for the oldest chunk if all the workers are busy
+func (d *parallelDeflater) dispatch(final bool) error {
+	for len(d.pending) >= d.workers {
+		if err := d.collect(); err != nil {
+			return err
+		}
+	}
+	chunk, dict := d.chunk, d.dict
+	d.dict = chunk
+	if len(chunk) > windowSize {
+		d.dict = chunk[len(chunk)-windowSize:]
+	} else if len(chunk) < windowSize {
+		// small chunks: the dictionary spans several of them
+		d.dict = append(append([]byte{}, dict...), chunk...)
+		if len(d.dict) > windowSize {
+			d.dict = d.dict[len(d.dict)-windowSize:]
+		}
+	}
+	d.chunk = nil // the chunk belongs to the goroutine now, and to the next dictionary
+
+	result := make(chan chunkResult, 1)
+	d.pending = append(d.pending, result)
+	go func() {
+		result <- compressChunk(chunk, dict, d.level, d.strategy, final)
+	}()
+	return nil
+}
+
+// collect waits for the oldest chunk being compressed, and writes it out
+func (d *parallelDeflater) collect() error {
+	result := <-d.pending[0]
+	d.pending = d.pending[1:]
+	if result.err != nil {
+		return result.err
+	}
+	d.checksum = crc32Combine(d.checksum, result.checksum, int64(result.length))
+	_, err := d.writer.Write(result.compressed)
+	return err
+}
+
+// compressChunk compresses a chunk into a standalone piece of deflate stream
+func compressChunk(chunk, dict []byte, level int, strategy Strategy, final bool) chunkResult {
+	var out bytes.Buffer
+	writer := newBitWriter(&out)
+	deflater, err := newDeflater(writer, level, strategy)
+	if err != nil {
+		return chunkResult{err: err}
+	}
+	deflater.preset(dict)
+	if err = deflater.write(chunk); err == nil {
+		if final {
+			err = deflater.close()
+		} else {
+			err = deflater.flush()
+		}
+	}
+	return chunkResult{compressed: out.Bytes(), checksum: crc32.ChecksumIEEE(chunk), length: len(chunk), err: err}
+}
@@ -0,0 +1,71 @@
+package gzip
+
+import (
+	"bytes"
+	stdgzip "compress/gzip"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func compressParallelForTest(t *testing.T, data []byte, level int, chunkSize, workers int) []byte {
+	var compressed bytes.Buffer
+	writer, err := NewWriterLevel(&compressed, level)
+	assert.NoError(t, err)
+	assert.NoError(t, writer.SetConcurrency(chunkSize, workers))
+	_, err = writer.Write(data)
+	assert.NoError(t, err)
+	assert.NoError(t, writer.Close())
+	return compressed.Bytes()
+}
+
+func TestParallelWriterRoundTrip(t *testing.T) {
+	for name, data := range testInputs() {
+		for _, chunkSize := range []int{1000, windowSize, 100000} {
+			compressed := compressParallelForTest(t, data, DefaultCompression, chunkSize, 4)
+
+			out, err := readGzipFile(bytes.NewReader(compressed))
+			assert.NoError(t, err)
+			assert.True(t, bytes.Equal(data, out), "%s, chunks of %d bytes", name, chunkSize)
+
+			// a single member: the standard library reads it all even without multistream
+			stdReader, err := stdgzip.NewReader(bytes.NewReader(compressed))
+			assert.NoError(t, err)
+			stdReader.Multistream(false)
+			out, err = io.ReadAll(stdReader)
+			assert.NoError(t, err)
+			assert.True(t, bytes.Equal(data, out), "%s, chunks of %d bytes", name, chunkSize)
+		}
+	}
+}
+
+func TestParallelWriterDeterministic(t *testing.T) {
+	data := bytes.Repeat(testInputs()["feynman.txt"], 10)
+	one := compressParallelForTest(t, data, DefaultCompression, 50000, 1)
+	many := compressParallelForTest(t, data, DefaultCompression, 50000, 8)
+	assert.Equal(t, one, many)
+
+	// priming each chunk with the previous one keeps the ratio close to the serial compression
+	serial := compressForTest(t, data)
+	assert.Less(t, len(many), len(serial)*11/10)
+}
+
+func TestParallelWriterLevels(t *testing.T) {
+	data, err := os.ReadFile("../attachment/feynman.txt")
+	if err != nil {
+		panic(err)
+	}
+	for _, level := range []int{NoCompression, BestSpeed, BestCompression} {
+		out, err := readGzipFile(bytes.NewReader(compressParallelForTest(t, data, level, 5000, 3)))
+		assert.NoError(t, err)
+		assert.Equal(t, data, out)
+	}
+
+	writer := NewWriter(io.Discard)
+	assert.Error(t, writer.SetConcurrency(1000, 0))
+	_, err = writer.Write([]byte("data"))
+	assert.NoError(t, err)
+	assert.Error(t, writer.SetConcurrency(1000, 2))
+}
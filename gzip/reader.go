@@ -0,0 +1,199 @@
+package gzip
+
+import (
+	"errors"
+	"hash"
+	"hash/crc32"
+	"io"
+)
+
+var errClosed = errors.New("gzip: read after Close")
+
+// Reader is an io.Reader that can be read to retrieve the uncompressed data of a gzip file.
+// A gzip file may be the concatenation of multiple members, each with its own metadata; Header holds the
+// metadata of the member currently being read.
+type Reader struct {
+	Header GzipMetaData
+
+	stream      *bitstream
+	decoder     *Decoder
+	multistream bool
+	inflater    *inflater   // inflater of the current member
+	digest      hash.Hash32 // CRC-32 of the current member, so far
+	size        uint32      // size of the current member (modulo 2^32), so far
+	err         error
+
+	memberOffset int64             // uncompressed offset of the start of the current member
+	memberStart  int64             // offset of the header of the current member in the gzip file
+	skipVerify   bool              // the current member wasn't read from its start (see Index), its trailer can't be verified
+	blockEnd     func(f *inflater) // passed on to the inflater of each member
+	memberEnd    func(m Member)    // called once the trailer of each member has been verified (see ListMembers)
+}
+
+// NewReader creates a new Reader reading the gzip file from r.
+// The metadata of the first member is read right away, so it is available in Header.
+func NewReader(r io.Reader) (*Reader, error) {
+	return (&Decoder{}).NewReader(r)
+}
+
+func newReader(r io.Reader, decoder *Decoder) *Reader {
+	return &Reader{
+		stream:      &bitstream{source: r},
+		decoder:     decoder,
+		multistream: true,
+		digest:      crc32.NewIEEE(),
+	}
+}
+
+// Multistream controls whether the reader supports multi-member gzip files (enabled by default).
+// If disabled, the reader returns io.EOF at the end of the current member, leaving the rest of the input unread
+// (apart from what has been buffered).
+func (z *Reader) Multistream(ok bool) {
+	z.multistream = ok
+}
+
+// Read inflates the data as it is being read, only holding on to the last 32 KiB.
+func (z *Reader) Read(p []byte) (n int, err error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	for n == 0 {
+		if z.err != nil {
+			return 0, z.err
+		}
+		n, err = z.inflater.Read(p)
+		z.digest.Write(p[:n])
+		z.size += uint32(n)
+		if err == io.EOF {
+			z.err = z.nextMember()
+		} else if err != nil {
+			z.err = err
+		}
+	}
+	return n, nil
+}
+
+// readMember reads the metadata of a member, and gets ready to inflate its data
+func (z *Reader) readMember() (err error) {
+	z.memberStart, _ = bitPosition(z.stream)
+	if z.Header, err = readGzipMetaData(z.stream); err != nil {
+		return err
+	}
+	if z.inflater != nil {
+		z.memberOffset += z.inflater.pos
+	}
+	z.inflater = newInflater(z.stream, z.decoder)
+	z.inflater.blockEnd = z.blockEnd
+	z.skipVerify = false
+	z.digest.Reset()
+	z.size = 0
+	header := z.Header // the tracer may keep it, while z.Header changes with the next member
+	z.decoder.trace(MemberStartEvent{Offset: z.memberOffset, Gzip: &header})
+	return nil
+}
+
+// nextMember verifies the trailer of the member that has just been inflated, then moves on to the next member
+func (z *Reader) nextMember() error {
+	trailer, err := readGzipTrailer(z.stream)
+	if err != nil {
+		return err
+	}
+	// the trailer can't be verified if the member wasn't read from its start
+	if !z.skipVerify {
+		if err := verifyGzipTrailer(trailer, z.digest.Sum32(), z.size); err != nil {
+			return decodeError(z.stream, err)
+		}
+	}
+	z.decoder.trace(MemberEndEvent{Size: z.inflater.pos, Verified: !z.skipVerify})
+	if z.memberEnd != nil {
+		end, _ := bitPosition(z.stream)
+		z.memberEnd(Member{
+			Offset:         z.memberStart,
+			CompressedSize: end - z.memberStart,
+			Size:           z.inflater.pos,
+			Header:         z.Header,
+			Trailer:        trailer,
+		})
+	}
+
+	if !z.multistream {
+		return io.EOF
+	}
+	if atEOF(z.stream) {
+		return io.EOF
+	}
+	// a member can only be followed by another member, or by zeros (padding the file to a tape block, which gzip
+	// ignores silently). Anything else is garbage: the data read so far is fine, but the file isn't.
+	magic, available, err := peekBits(z.stream, 16)
+	if err != nil {
+		return err
+	}
+	if available < 16 || magic != 0x8b1f {
+		offset, _ := bitPosition(z.stream)
+		if skipZeros(z.stream) {
+			return io.EOF
+		}
+		return &DecodeError{Err: ErrTrailingGarbage, Offset: offset}
+	}
+	return z.readMember()
+}
+
+// skipZeros consumes the rest of the input, it returns false if it isn't only zeros
+func skipZeros(stream *bitstream) bool {
+	for !atEOF(stream) {
+		if b, err := readBitsInv(stream, 8); err != nil || b != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// Close releases the inflater, it does not close the underlying io.Reader
+func (z *Reader) Close() error {
+	z.inflater = nil
+	z.err = errClosed
+	return nil
+}
+
+// RawReader is an io.Reader that inflates a raw deflate stream (RFC 1951), with no container around it (as found
+// in zip files or WebSocket messages). There is no trailer, so nothing to verify the data against.
+type RawReader struct {
+	stream   *bitstream
+	inflater *inflater
+	err      error
+}
+
+// NewRawReader creates a new RawReader inflating the deflate stream from r.
+// The input is read ahead in chunks, so r is not positioned right after the stream once it has been inflated.
+func NewRawReader(r io.Reader) *RawReader {
+	return NewRawReaderDict(r, nil)
+}
+
+// NewRawReaderDict is like NewRawReader, but back-pointers can also refer to a preset dictionary, which has to be
+// the one the stream was compressed with. The dictionary itself is not part of the output.
+func NewRawReaderDict(r io.Reader, dict []byte) *RawReader {
+	return newRawReader(r, dict, &Decoder{})
+}
+
+func newRawReader(r io.Reader, dict []byte, decoder *Decoder) *RawReader {
+	stream := &bitstream{source: r}
+	inflater := newInflater(stream, decoder)
+	inflater.window.preset(dict)
+	return &RawReader{stream: stream, inflater: inflater}
+}
+
+func (z *RawReader) Read(p []byte) (int, error) {
+	if z.err != nil {
+		return 0, z.err
+	}
+	n, err := z.inflater.Read(p)
+	z.err = err
+	return n, err
+}
+
+// Close releases the inflater, it does not close the underlying io.Reader
+func (z *RawReader) Close() error {
+	z.inflater = nil
+	z.err = errClosed
+	return nil
+}
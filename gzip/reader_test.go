@@ -0,0 +1,154 @@
+package gzip
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReader(t *testing.T) {
+	expected, err := os.ReadFile("../attachment/let_it_be.txt")
+	if err != nil {
+		panic(err)
+	}
+	file, err := os.Open("../attachment/let_it_be.txt.gz")
+	if err != nil {
+		panic(err)
+	}
+	defer file.Close()
+
+	reader, err := NewReader(file)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("let_it_be.txt"), reader.Header.Fname)
+
+	out, err := io.ReadAll(reader)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, out)
+
+	assert.NoError(t, reader.Close())
+	_, err = reader.Read(make([]byte, 1))
+	assert.Error(t, err)
+}
+
+func TestReaderMultistream(t *testing.T) {
+	expected, err := os.ReadFile("../attachment/let_it_be.txt")
+	if err != nil {
+		panic(err)
+	}
+	compressed, err := os.ReadFile("../attachment/let_it_be.txt.gz")
+	if err != nil {
+		panic(err)
+	}
+	stored, err := os.ReadFile("../attachment/let_it_be_stored.txt.gz")
+	if err != nil {
+		panic(err)
+	}
+	concatenated := append(append([]byte{}, compressed...), stored...)
+
+	reader, err := NewReader(bytes.NewReader(concatenated))
+	assert.NoError(t, err)
+	out, err := io.ReadAll(reader)
+	assert.NoError(t, err)
+	assert.Equal(t, append(append([]byte{}, expected...), expected...), out)
+	// Header holds the metadata of the last member (which has no FNAME)
+	assert.Nil(t, reader.Header.Fname)
+
+	reader, err = NewReader(bytes.NewReader(concatenated))
+	assert.NoError(t, err)
+	reader.Multistream(false)
+	out, err = io.ReadAll(reader)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, out)
+}
+
+func TestReaderErrors(t *testing.T) {
+	_, err := NewReader(bytes.NewReader([]byte{0x1f, 0x8c, 0x08, 0, 0, 0, 0, 0, 0, 0}))
+	assert.ErrorIs(t, err, ErrBadMagic)
+
+	original, err := os.ReadFile("../attachment/let_it_be_stored.txt.gz")
+	if err != nil {
+		panic(err)
+	}
+	corrupted := append([]byte{}, original...)
+	corrupted[len(corrupted)-8] ^= 0xFF
+	reader, err := NewReader(bytes.NewReader(corrupted))
+	assert.NoError(t, err)
+	_, err = io.ReadAll(reader)
+	assert.ErrorIs(t, err, ErrChecksum)
+}
+
+func TestRawReader(t *testing.T) {
+	expected, err := os.ReadFile("../attachment/let_it_be.txt")
+	if err != nil {
+		panic(err)
+	}
+	file, err := os.Open("../attachment/let_it_be.txt.deflate")
+	if err != nil {
+		panic(err)
+	}
+	defer file.Close()
+
+	reader := NewRawReader(file)
+	out, err := io.ReadAll(reader)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, out)
+
+	assert.NoError(t, reader.Close())
+	_, err = reader.Read(make([]byte, 1))
+	assert.Error(t, err)
+}
+
+func TestRawReaderDict(t *testing.T) {
+	expected, err := os.ReadFile("../attachment/let_it_be.txt")
+	if err != nil {
+		panic(err)
+	}
+	compressed, err := os.ReadFile("../attachment/let_it_be_dict.txt.deflate")
+	if err != nil {
+		panic(err)
+	}
+	dict := []byte("Let it be, let it be\nWhisper words of wisdom, let it be\n")
+
+	out, err := io.ReadAll(NewRawReaderDict(bytes.NewReader(compressed), dict))
+	assert.NoError(t, err)
+	assert.Equal(t, expected, out)
+
+	// without the dictionary, the first back-pointers reach before the start of the stream
+	_, err = io.ReadAll(NewRawReader(bytes.NewReader(compressed)))
+	assert.ErrorIs(t, err, ErrInvalidDistance)
+}
+
+func TestReaderTrailingGarbage(t *testing.T) {
+	expected, err := os.ReadFile("../attachment/let_it_be.txt")
+	if err != nil {
+		panic(err)
+	}
+	compressed, err := os.ReadFile("../attachment/let_it_be.txt.gz")
+	if err != nil {
+		panic(err)
+	}
+	// zeros padding the file are ignored, like gzip does
+	for _, zeros := range [][]byte{{0}, make([]byte, 512), make([]byte, 10000)} {
+		reader, err := NewReader(bytes.NewReader(append(append([]byte{}, compressed...), zeros...)))
+		assert.NoError(t, err)
+		out, err := io.ReadAll(reader)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, out)
+	}
+
+	for _, garbage := range [][]byte{{0x1f}, []byte("not a gzip member"), append(make([]byte, 100), 1)} {
+		reader, err := NewReader(bytes.NewReader(append(append([]byte{}, compressed...), garbage...)))
+		assert.NoError(t, err)
+		out, err := io.ReadAll(reader)
+		// all the data comes out before the error
+		assert.Equal(t, expected, out)
+		assert.ErrorIs(t, err, ErrTrailingGarbage)
+		var decodeErr *DecodeError
+		assert.True(t, errors.As(err, &decodeErr))
+		assert.Equal(t, int64(len(compressed)), decodeErr.Offset)
+	}
+}
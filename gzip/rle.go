@@ -1,4 +1,4 @@
-package main
+package gzip
 
 type rleRange struct {
 	end       int // set bitLength until index end
@@ -1,4 +1,4 @@
-package main
+package gzip
 
 import (
 	"github.com/stretchr/testify/assert"
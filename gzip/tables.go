@@ -0,0 +1,145 @@
+package gzip
+
+// The tables of the deflate format, shared by the decoder (inflater) and the encoder (deflater).
+
+// The bit lengths of the fixed huffman codes (block type 0b01).
+// Like the dynamic trees, the literals tree uses 1-indexing.
+var fixedLiteralRanges = []rleRange{
+	{0, 0},
+	{144, 8},
+	{256, 9},
+	{280, 7},
+	{288, 8},
+}
+
+// The distance codes are all 5 bits long, they are followed by extra bits just like in dynamic blocks
+var fixedDistanceRanges = []rleRange{
+	{31, 5},
+}
+
+// The bit lengths of the code lengths alphabet are written in this order (see readCodesBitLengths).
+// Because codes of lengths 15, 1, 14 and 2 are likely to be very rare in real-world data,
+// the codes themselves are given in order of expected frequency
+var codeLengthOrder = []int{
+	16, 17, 18, 0, 8, 7, 9, 6, 10, 5, 11, 4, 12, 3, 13, 2, 14, 1, 15,
+}
+
+/*
+
+reading LZ77:
+
+Format is always Length|Distance
+
+- codes 257-264: length is $code - 254 (no extra length bits)
+- codes 265-285: have extra bits length
+
+
+Distance codes:
+Can range from 1-32768
+*/
+
+/*
+Now, if there are only 285-257=28 length codes, that doesn't give the LZ77 compressor much room to
+reuse previous input. Instead, the deflate format uses the 28 pointer codes as an indication to the
+decompressor as to how many extra bits follow which indicate the actual length of the match.
+*/
+
+/*
+What's with this extraLengthAddend?
+It is used as: length = readBitsInv(stream, (node.code - 261)/4) + extraLengthAddend[node.code - 265]
+for node.code in [265, 285)
+Code: 265, base value: 11, max_value: 12
+Code: 266, base value: 13, max_value: 14
+Code: 267, base value: 15, max_value: 16
+Code: 268, base value: 17, max_value: 18
+Code: 269, base value: 19, max_value: 22
+Code: 270, base value: 23, max_value: 26
+Code: 271, base value: 27, max_value: 30
+Code: 272, base value: 31, max_value: 34
+Code: 273, base value: 35, max_value: 42
+Code: 274, base value: 43, max_value: 50
+Code: 275, base value: 51, max_value: 58
+Code: 276, base value: 59, max_value: 66
+Code: 277, base value: 67, max_value: 82
+Code: 278, base value: 83, max_value: 98
+Code: 279, base value: 99, max_value: 114
+Code: 280, base value: 115, max_value: 130
+Code: 281, base value: 131, max_value: 162
+Code: 282, base value: 163, max_value: 194
+Code: 283, base value: 195, max_value: 226
+Code: 284, base value: 227, max_value: 258
+*/
+var extraLengthAddend = []int{
+	11, 13, 15, 17, 19, 23, 27,
+	31, 35, 43, 51, 59, 67, 83,
+	99, 115, 131, 163, 195, 227,
+}
+
+/*
+We only support until distance code 29 instead of until 31 because it's sufficient to describe until 32KiB distance
+Dist Code: 4, base value: 4, max_value: 5
+Dist Code: 5, base value: 6, max_value: 7
+Dist Code: 6, base value: 8, max_value: 11
+Dist Code: 7, base value: 12, max_value: 15
+Dist Code: 8, base value: 16, max_value: 23
+Dist Code: 9, base value: 24, max_value: 31
+Dist Code: 10, base value: 32, max_value: 47
+Dist Code: 11, base value: 48, max_value: 63
+Dist Code: 12, base value: 64, max_value: 95
+Dist Code: 13, base value: 96, max_value: 127
+Dist Code: 14, base value: 128, max_value: 191
+Dist Code: 15, base value: 192, max_value: 255
+Dist Code: 16, base value: 256, max_value: 383
+Dist Code: 17, base value: 384, max_value: 511
+Dist Code: 18, base value: 512, max_value: 767
+Dist Code: 19, base value: 768, max_value: 1023
+Dist Code: 20, base value: 1024, max_value: 1535
+Dist Code: 21, base value: 1536, max_value: 2047
+Dist Code: 22, base value: 2048, max_value: 3071
+Dist Code: 23, base value: 3072, max_value: 4095
+Dist Code: 24, base value: 4096, max_value: 6143
+Dist Code: 25, base value: 6144, max_value: 8191
+Dist Code: 26, base value: 8192, max_value: 12287
+Dist Code: 27, base value: 12288, max_value: 16383
+Dist Code: 28, base value: 16384, max_value: 24575
+Dist Code: 29, base value: 24576, max_value: 32767
+*/
+var extraDistAddend = []int{
+	4, 6, 8, 12, 16, 24, 32, 48,
+	64, 96, 128, 192, 256, 384,
+	512, 768, 1024, 1536, 2048,
+	3072, 4096, 6144, 8192,
+	12288, 16384, 24576,
+}
+
+// lengthSymbol is the reverse of the length decoding in inflateHuffmanCodes: it returns the length code (257-285)
+// of a back-pointer length (3-258), along with the extra bits that follow it.
+func lengthSymbol(length int) (code int, extraBits int, extra int) {
+	if length < 11 {
+		return length + 254, 0, 0
+	}
+	if length == 258 {
+		return 285, 0, 0
+	}
+	i := len(extraLengthAddend) - 1
+	for extraLengthAddend[i] > length {
+		i--
+	}
+	code = i + 265
+	return code, (code - 261) / 4, length - extraLengthAddend[i]
+}
+
+// distanceSymbol returns the distance code (0-29) of a back-pointer distance (1-32768), along with the extra bits
+// that follow it.
+func distanceSymbol(dist int) (code int, extraBits int, extra int) {
+	dist -= 1 // the codes start from a distance of 1
+	if dist < 4 {
+		return dist, 0, 0
+	}
+	i := len(extraDistAddend) - 1
+	for extraDistAddend[i] > dist {
+		i--
+	}
+	code = i + 4
+	return code, (code - 2) / 2, dist - extraDistAddend[i]
+}
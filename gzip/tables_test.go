@@ -0,0 +1,39 @@
+package gzip
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLengthSymbol(t *testing.T) {
+	for length := minMatch; length <= maxMatch; length++ {
+		code, extraBits, extra := lengthSymbol(length)
+		assert.True(t, code >= 257 && code <= 285)
+		assert.Less(t, extra, 1<<extraBits)
+		// decode it the way inflateHuffmanCodes does
+		switch {
+		case code < 265:
+			assert.Equal(t, length, code-254)
+		case code == 285:
+			assert.Equal(t, 258, length)
+		default:
+			assert.Equal(t, (code-261)/4, extraBits)
+			assert.Equal(t, length, extraLengthAddend[code-265]+extra)
+		}
+	}
+}
+
+func TestDistanceSymbol(t *testing.T) {
+	for dist := 1; dist <= windowSize; dist++ {
+		code, extraBits, extra := distanceSymbol(dist)
+		assert.True(t, code >= 0 && code <= 29)
+		assert.Less(t, extra, 1<<extraBits)
+		if code > 3 {
+			assert.Equal(t, (code-2)/2, extraBits)
+			assert.Equal(t, dist, extraDistAddend[code-4]+extra+1)
+		} else {
+			assert.Equal(t, dist, code+1)
+		}
+	}
+}
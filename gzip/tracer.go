@@ -0,0 +1,207 @@
+package gzip
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+/*
+Tracing:
+
+The decoder doesn't print anything itself. Instead, it tells a Tracer (if the Decoder has one) what it finds as it
+goes: the start of each member and block, every literal and back-pointer, the end of each block and member. The
+TextTracer prints them the way the CLI does, and anything else (a visualization, a test, a profiler) can subscribe
+the same way.
+
+The offsets of the events are offsets in the uncompressed data of the current member (or stream, for zlib and raw
+deflate), apart from MemberStartEvent which gives the offset of the member in the whole gzip file.
+*/
+
+// Tracer receives the events of a Decoder, in order. The event is one of the *Event types of this file.
+type Tracer interface {
+	Trace(event TraceEvent)
+}
+
+// TraceEvent is implemented by the events sent to a Tracer
+type TraceEvent interface {
+	traceEvent()
+}
+
+// MemberStartEvent is sent once the header of a gzip member or a zlib stream has been read (not for raw deflate)
+type MemberStartEvent struct {
+	Offset     int64         // uncompressed offset of the start of the member, in the whole file
+	Gzip       *GzipMetaData // the header of a gzip member, nil otherwise
+	Zlib       *ZlibMetaData // the header of a zlib stream, nil otherwise
+	Dictionary []byte        // the preset dictionary of the zlib stream, if any
+}
+
+// MemberEndEvent is sent after the trailer of a gzip member or a zlib stream has been read
+type MemberEndEvent struct {
+	Size     int64 // uncompressed size of the member
+	Verified bool  // the checksum and size have been verified (not possible if the member wasn't read from its start)
+}
+
+// Block types, as written in BTYPE
+const (
+	StoredBlock  = 0b00
+	FixedBlock   = 0b01
+	DynamicBlock = 0b10
+)
+
+// BlockStartEvent is sent once the header of a block (and its huffman codes, if any) has been read
+type BlockStartEvent struct {
+	Offset         int64 // uncompressed offset of the block
+	CompressedBits int64 // position of the block header in the compressed input, in bits
+	Type           int   // StoredBlock, FixedBlock or DynamicBlock
+	Final          bool
+
+	StoredLength int // number of bytes of a stored block
+
+	// numbers of codes of a dynamic block: literal/length codes (HLIT + 257), distance codes (HDIST + 1) and code
+	// length codes (HCLEN + 4)
+	LiteralCodes, DistanceCodes, CodeLengthCodes int
+}
+
+// LiteralEvent is sent for each literal of a huffman block
+type LiteralEvent struct {
+	Offset int64
+	Value  byte
+}
+
+// MatchEvent is sent for each back-pointer of a huffman block
+type MatchEvent struct {
+	Offset   int64
+	Length   int
+	Distance int
+}
+
+// Source returns the offset of the bytes being copied
+func (e MatchEvent) Source() int64 {
+	return e.Offset - int64(e.Distance)
+}
+
+// StoredEvent is sent for the bytes of a stored block, which may come in several pieces
+type StoredEvent struct {
+	Offset int64
+	Data   []byte // only valid during the call to Trace
+}
+
+// BlockEndEvent is sent at the end of each block
+type BlockEndEvent struct {
+	Offset int64 // uncompressed offset of the end of the block
+	Final  bool
+}
+
+func (MemberStartEvent) traceEvent() {}
+func (MemberEndEvent) traceEvent()   {}
+func (BlockStartEvent) traceEvent()  {}
+func (LiteralEvent) traceEvent()     {}
+func (MatchEvent) traceEvent()       {}
+func (StoredEvent) traceEvent()      {}
+func (BlockEndEvent) traceEvent()    {}
+
+// TextTracer prints the events as text: the structure of the stream (Explain), and/or the inflated data itself as
+// it is decoded (Inline).
+type TextTracer struct {
+	Output       io.Writer // os.Stdout if nil
+	Explain      bool      // explain the structure of the stream (block types, tree sizes, ...)
+	Inline       bool      // print the inflated data
+	Slow         bool      // sleep after each literal and back-pointer, only effective with Inline
+	BackPointers bool      // print the back-pointers as <source,length>(...), only effective with Inline
+
+	// the events only give the distance of the back-pointers, so the tracer keeps the last 32 KiB to print them
+	history []byte
+}
+
+func (t *TextTracer) Trace(event TraceEvent) {
+	switch e := event.(type) {
+	case MemberStartEvent:
+		t.history = append(t.history[:0], e.Dictionary...)
+		if e.Gzip != nil {
+			t.explain("gzip member at offset %d: %v", e.Offset, DecodeHeader(*e.Gzip))
+		}
+		if e.Zlib != nil {
+			t.explain("zlib header, CMF %02x FLG %02x", e.Zlib.Header.CMF, e.Zlib.Header.FLG)
+			if e.Dictionary != nil {
+				t.explain("using a preset dictionary of %d bytes", len(e.Dictionary))
+			}
+		}
+	case MemberEndEvent:
+		if !e.Verified {
+			t.explain("\nend of member, %d bytes (not verified, the member wasn't read from its start)", e.Size)
+		} else {
+			t.explain("\nend of member, %d bytes", e.Size)
+		}
+	case BlockStartEvent:
+		switch e.Type {
+		case StoredBlock:
+			t.explain("block 0b00, uncompressed")
+			t.explain("stored block of %d bytes", e.StoredLength)
+		case FixedBlock:
+			t.explain("block 0b01, using fixed huffman tree")
+		case DynamicBlock:
+			t.explain("block 0b10, using dynamic huffman tree")
+			t.explain("hlit: %d (number of (extra) length literals)", e.LiteralCodes-257)
+			t.explain("hdist: %d (number of distance codes)", e.DistanceCodes-1)
+			t.explain("hclen: %d (number of huffman code length for the first tree)", e.CodeLengthCodes-4)
+		}
+	case LiteralEvent:
+		t.print([]byte{e.Value})
+		t.slowDown()
+	case MatchEvent:
+		copied := make([]byte, e.Length)
+		for i := range copied {
+			// the copy may overlap the bytes it produces (when the distance is smaller than the length)
+			switch source := len(t.history) - e.Distance + i; {
+			case source < 0:
+				copied[i] = '?' // before what the tracer has seen (e.g. a stream resumed in the middle)
+			case source < len(t.history):
+				copied[i] = t.history[source]
+			default:
+				copied[i] = copied[source-len(t.history)]
+			}
+		}
+		if t.Inline && t.BackPointers {
+			fmt.Fprintf(t.output(), "<%d,%d>(", e.Source(), e.Length)
+		}
+		t.print(copied)
+		if t.Inline && t.BackPointers {
+			fmt.Fprint(t.output(), ")")
+		}
+		t.slowDown()
+	case StoredEvent:
+		t.print(e.Data)
+	}
+}
+
+func (t *TextTracer) output() io.Writer {
+	if t.Output == nil {
+		return os.Stdout
+	}
+	return t.Output
+}
+
+func (t *TextTracer) explain(format string, a ...interface{}) {
+	if t.Explain {
+		fmt.Fprintf(t.output(), format+"\n", a...)
+	}
+}
+
+// print prints inflated bytes (with Inline), and adds them to the history
+func (t *TextTracer) print(data []byte) {
+	if t.Inline {
+		t.output().Write(data)
+	}
+	t.history = append(t.history, data...)
+	if len(t.history) > 2*windowSize {
+		t.history = append(t.history[:0], t.history[len(t.history)-windowSize:]...)
+	}
+}
+
+func (t *TextTracer) slowDown() {
+	if t.Inline && t.Slow {
+		time.Sleep(50 * time.Millisecond)
+	}
+}
@@ -0,0 +1,120 @@
+package gzip
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingTracer struct {
+	events []TraceEvent
+}
+
+func (r *recordingTracer) Trace(event TraceEvent) {
+	if stored, ok := event.(StoredEvent); ok {
+		stored.Data = append([]byte{}, stored.Data...) // only valid during the call
+		event = stored
+	}
+	r.events = append(r.events, event)
+}
+
+func TestTracerEvents(t *testing.T) {
+	// two members: the first one with dynamic blocks, the second one stored
+	first := bytes.Repeat(testInputs()["feynman.txt"], 2)
+	second := []byte("let it be, let it be")
+	var compressed bytes.Buffer
+	for _, member := range []struct {
+		data  []byte
+		level int
+	}{{first, DefaultCompression}, {second, NoCompression}} {
+		writer, err := NewWriterLevel(&compressed, member.level)
+		assert.NoError(t, err)
+		_, err = writer.Write(member.data)
+		assert.NoError(t, err)
+		assert.NoError(t, writer.Close())
+	}
+
+	tracer := &recordingTracer{}
+	decoder := &Decoder{Tracer: tracer}
+	reader, err := decoder.NewReader(&compressed)
+	assert.NoError(t, err)
+	_, err = io.ReadAll(reader)
+	assert.NoError(t, err)
+
+	// replay the events to rebuild the data of each member
+	var members [][]byte
+	var current []byte
+	var blockTypes []int
+	literals, matches, inBlock := 0, 0, false
+	for _, event := range tracer.events {
+		switch e := event.(type) {
+		case MemberStartEvent:
+			assert.NotNil(t, e.Gzip)
+			assert.Equal(t, int64(len(members)*len(first)), e.Offset)
+			current = nil
+		case BlockStartEvent:
+			assert.False(t, inBlock)
+			assert.Equal(t, int64(len(current)), e.Offset)
+			inBlock = true
+			blockTypes = append(blockTypes, e.Type)
+			if e.Type == DynamicBlock {
+				assert.GreaterOrEqual(t, e.LiteralCodes, 257)
+				assert.GreaterOrEqual(t, e.CodeLengthCodes, 4)
+			}
+		case LiteralEvent:
+			assert.Equal(t, int64(len(current)), e.Offset)
+			current = append(current, e.Value)
+			literals++
+		case MatchEvent:
+			assert.Equal(t, int64(len(current)), e.Offset)
+			for i := 0; i < e.Length; i++ {
+				current = append(current, current[e.Source()+int64(i)])
+			}
+			matches++
+		case StoredEvent:
+			current = append(current, e.Data...)
+		case BlockEndEvent:
+			assert.True(t, inBlock)
+			inBlock = false
+		case MemberEndEvent:
+			assert.True(t, e.Verified)
+			assert.Equal(t, int64(len(current)), e.Size)
+			members = append(members, current)
+		}
+	}
+	assert.Equal(t, [][]byte{first, second}, members)
+	assert.Equal(t, DynamicBlock, blockTypes[0])
+	assert.Equal(t, StoredBlock, blockTypes[len(blockTypes)-1])
+	assert.Equal(t, decoder.LiteralCount, literals)
+	assert.Equal(t, decoder.BackPointerCount, matches)
+}
+
+func TestTextTracer(t *testing.T) {
+	compressed := compressForTest(t, []byte("hello hello hello world"))
+	var printed bytes.Buffer
+	decoder := &Decoder{Tracer: &TextTracer{Output: &printed, Inline: true, BackPointers: true}}
+	reader, err := decoder.NewReader(bytes.NewReader(compressed))
+	assert.NoError(t, err)
+	_, err = io.ReadAll(reader)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello <0,12>(hello hello )world", printed.String())
+
+	// the zlib dictionary is part of the history back-pointers can refer to
+	dictCompressed, err := os.ReadFile("../attachment/let_it_be_dict.txt.zlib")
+	assert.NoError(t, err)
+	expected, err := os.ReadFile("../attachment/let_it_be.txt")
+	assert.NoError(t, err)
+	printed.Reset()
+	decoder = &Decoder{Tracer: &TextTracer{Output: &printed, Inline: true, Explain: true}}
+	zlibReader, err := decoder.NewZlibReaderDict(bytes.NewReader(dictCompressed), []byte("Let it be, let it be\nWhisper words of wisdom, let it be\n"))
+	assert.NoError(t, err)
+	_, err = io.ReadAll(zlibReader)
+	assert.NoError(t, err)
+	assert.Contains(t, printed.String(), "using a preset dictionary of 56 bytes")
+	assert.Contains(t, printed.String(), string(expected))
+	assert.True(t, strings.HasPrefix(printed.String(), "zlib header"))
+}
@@ -0,0 +1,102 @@
+package gzip
+
+// windowSize is the furthest a back-pointer can reach (distance code 29 goes up to 32768)
+const windowSize = 1 << 15
+
+// slidingWindow keeps the last 32 KiB of inflated bytes, which is all the history back-pointers can refer to.
+// The inflated bytes are written straight into hist, and have to be flushed (handed out to the reader) once hist
+// is full, before wrapping around and overwriting the oldest bytes.
+type slidingWindow struct {
+	hist  []byte
+	wrPos int  // position of the next byte to write
+	rdPos int  // bytes before rdPos have been flushed
+	full  bool // hist has wrapped around at least once, so all of it is valid history
+}
+
+func newSlidingWindow() slidingWindow {
+	return slidingWindow{hist: make([]byte, windowSize)}
+}
+
+// preset fills the history with a preset dictionary, as if it had been inflated (and flushed) already.
+// Only the last 32 KiB of the dictionary can be referred to.
+func (w *slidingWindow) preset(dict []byte) {
+	if len(dict) >= len(w.hist) {
+		copy(w.hist, dict[len(dict)-len(w.hist):])
+		w.wrPos, w.rdPos = 0, 0
+		w.full = true
+		return
+	}
+	w.wrPos = copy(w.hist, dict)
+	w.rdPos = w.wrPos
+}
+
+// snapshot returns a copy of the history, the oldest byte first
+func (w *slidingWindow) snapshot() []byte {
+	if !w.full {
+		return append([]byte{}, w.hist[:w.wrPos]...)
+	}
+	return append(append([]byte{}, w.hist[w.wrPos:]...), w.hist[:w.wrPos]...)
+}
+
+// available returns how many bytes can be written before the window needs to be flushed
+func (w *slidingWindow) available() int {
+	return len(w.hist) - w.wrPos
+}
+
+// historySize returns how many bytes back a back-pointer is allowed to reach
+func (w *slidingWindow) historySize() int {
+	if w.full {
+		return len(w.hist)
+	}
+	return w.wrPos
+}
+
+func (w *slidingWindow) writeByte(b byte) {
+	w.hist[w.wrPos] = b
+	w.wrPos++
+}
+
+// writeSlice returns the free space of the window, to be filled directly (e.g. by a stored block) and committed
+// with advance
+func (w *slidingWindow) writeSlice() []byte {
+	return w.hist[w.wrPos:]
+}
+
+func (w *slidingWindow) advance(count int) {
+	w.wrPos += count
+}
+
+// writeCopy copies length bytes starting dist bytes back, or as many as there is space for. The copy may overlap
+// the bytes being written (when length > dist), that's how a short sequence gets repeated.
+// It returns the written bytes.
+func (w *slidingWindow) writeCopy(dist, length int) []byte {
+	start := w.wrPos
+	end := w.wrPos + length
+	if end > len(w.hist) {
+		end = len(w.hist)
+	}
+	src := w.wrPos - dist
+	if src < 0 {
+		src += len(w.hist) // the history wrapped around
+	}
+	for w.wrPos < end {
+		w.hist[w.wrPos] = w.hist[src]
+		w.wrPos++
+		src++
+		if src == len(w.hist) {
+			src = 0
+		}
+	}
+	return w.hist[start:end]
+}
+
+// flush returns the bytes written since the last flush. They stay valid until the window is written again.
+func (w *slidingWindow) flush() []byte {
+	toRead := w.hist[w.rdPos:w.wrPos]
+	w.rdPos = w.wrPos
+	if w.wrPos == len(w.hist) {
+		w.wrPos, w.rdPos = 0, 0
+		w.full = true
+	}
+	return toRead
+}
@@ -0,0 +1,74 @@
+package gzip
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlidingWindowWriteCopy(t *testing.T) {
+	window := newSlidingWindow()
+	window.writeByte('a')
+	window.writeByte('b')
+	assert.Equal(t, 2, window.historySize())
+
+	// length > dist, the copy repeats the bytes it has just written
+	assert.Equal(t, []byte("ababa"), window.writeCopy(2, 5))
+	assert.Equal(t, []byte("abababa"), window.flush())
+	assert.Empty(t, window.flush())
+}
+
+func TestSlidingWindowWrapAround(t *testing.T) {
+	window := newSlidingWindow()
+	window.advance(window.available() - 2)
+	copy(window.hist[windowSize-5:], "xyz") // the last 3 bytes written
+
+	// only 2 bytes fit before the window needs to be flushed
+	assert.Equal(t, []byte("xy"), window.writeCopy(3, 3))
+	assert.Len(t, window.flush(), windowSize)
+	assert.Equal(t, windowSize, window.historySize())
+
+	// the rest of the copy reaches back into the end of the window
+	assert.Equal(t, []byte("z"), window.writeCopy(3, 1))
+	assert.Equal(t, []byte("z"), window.flush())
+}
+
+func TestSlidingWindowPreset(t *testing.T) {
+	window := newSlidingWindow()
+	window.preset([]byte("abc"))
+	assert.Equal(t, 3, window.historySize())
+	assert.Equal(t, []byte("bcbc"), window.writeCopy(2, 4))
+	// the dictionary is history, not output
+	assert.Equal(t, []byte("bcbc"), window.flush())
+
+	large := make([]byte, windowSize+10)
+	large[10] = 'x'
+	window = newSlidingWindow()
+	window.preset(large)
+	assert.Equal(t, windowSize, window.historySize())
+	assert.Equal(t, []byte("x"), window.writeCopy(windowSize, 1))
+}
+
+func TestInflateBoundedMemory(t *testing.T) {
+	// 256 stored blocks of 64 KiB - 1 zeros, followed by an empty final block
+	block := append([]byte{0b0000_0_00_0, 0xFF, 0xFF, 0x00, 0x00}, make([]byte, 0xFFFF)...)
+	var blocks []io.Reader
+	for i := 0; i < 256; i++ {
+		blocks = append(blocks, bytes.NewReader(block))
+	}
+	blocks = append(blocks, bytes.NewReader([]byte{0b0000_0_00_1, 0x00, 0x00, 0xFF, 0xFF}))
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	inflated, err := io.Copy(io.Discard, newInflater(&bitstream{source: bufio.NewReader(io.MultiReader(blocks...))}, nil))
+	runtime.ReadMemStats(&after)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(256*0xFFFF), inflated)
+	// ~16 MiB were inflated, but only the window (and a few buffers) should have been allocated
+	assert.Less(t, after.TotalAlloc-before.TotalAlloc, uint64(1<<20))
+}
@@ -0,0 +1,157 @@
+package gzip
+
+import (
+	"errors"
+	"hash"
+	"hash/crc32"
+	"io"
+)
+
+var errWriterClosed = errors.New("gzip: write after Close")
+
+// Writer is an io.WriteCloser compressing what is written to it into a gzip file (a single member).
+// Close must be called to write the end of the deflate stream and the trailer.
+type Writer struct {
+	// Header is written along with the first compressed bytes, so its fields (e.g. Fname, Header.Mtime) can be set
+	// until then. ID, CompressionMethod and the flags of the optional fields are filled in by the Writer.
+	Header GzipMetaData
+
+	writer      *bitWriter
+	level       int
+	strategy    Strategy
+	deflater    compressor
+	parallel    *parallelDeflater // set by SetConcurrency, it is also the deflater
+	wroteHeader bool
+	digest      hash.Hash32 // CRC-32 of the data, so far
+	size        uint32      // size of the data (modulo 2^32), so far
+	err         error
+}
+
+// compressor is what the Writer compresses the data with: the deflater, or the parallelDeflater
+type compressor interface {
+	write(p []byte) error
+	close() error
+}
+
+// NewWriter creates a new Writer writing the gzip file to w, with the default compression level
+func NewWriter(w io.Writer) *Writer {
+	z, _ := NewWriterLevel(w, DefaultCompression)
+	return z
+}
+
+// NewWriterLevel is like NewWriter, with the compression level: from NoCompression (0) or BestSpeed (1) to
+// BestCompression (9) or OptimalCompression (10), or DefaultCompression
+func NewWriterLevel(w io.Writer, level int) (*Writer, error) {
+	return NewWriterLevelStrategy(w, level, DefaultStrategy)
+}
+
+// NewWriterLevelStrategy is like NewWriterLevel, with a Strategy suited to the data
+func NewWriterLevelStrategy(w io.Writer, level int, strategy Strategy) (*Writer, error) {
+	writer := newBitWriter(w)
+	deflater, err := newDeflater(writer, level, strategy)
+	if err != nil {
+		return nil, err
+	}
+	z := &Writer{
+		writer:   writer,
+		level:    level,
+		strategy: strategy,
+		deflater: deflater,
+		digest:   crc32.NewIEEE(),
+	}
+	z.Header.Header.OS = 255 // unknown
+	// XFL tells whether the slowest (2) or the fastest (4) algorithm was used
+	switch {
+	case level >= BestCompression:
+		z.Header.Header.ExtraFlags = 2
+	case level == BestSpeed:
+		z.Header.Header.ExtraFlags = 4
+	}
+	return z, nil
+}
+
+// SetConcurrency makes the Writer compress chunks of chunkSize bytes (128 KiB if 0) in parallel, with up to workers
+// goroutines. The output is still a single gzip member, only slightly larger. It must be called before the first
+// Write.
+func (z *Writer) SetConcurrency(chunkSize, workers int) error {
+	if z.wroteHeader {
+		return errors.New("gzip: SetConcurrency called after Write")
+	}
+	if chunkSize == 0 {
+		chunkSize = defaultChunkSize
+	}
+	parallel, err := newParallelDeflater(z.writer, z.level, z.strategy, chunkSize, workers)
+	if err != nil {
+		return err
+	}
+	z.deflater, z.parallel = parallel, parallel
+	return nil
+}
+
+// SetHeader sets the metadata of the member from a Header, it must be called before the first Write. Start from
+// DecodeHeader(z.Header) to keep what NewWriter filled in (OS, XFL).
+func (z *Writer) SetHeader(header Header) error {
+	if z.wroteHeader {
+		return errors.New("gzip: SetHeader called after Write")
+	}
+	gzipMetaData, err := EncodeHeader(header)
+	if err != nil {
+		return err
+	}
+	z.Header = gzipMetaData
+	return nil
+}
+
+func (z *Writer) writeHeader() error {
+	if z.wroteHeader {
+		return nil
+	}
+	z.wroteHeader = true
+	return writeGzipMetaData(z.writer, z.Header)
+}
+
+// Write compresses p. The compressed data is buffered, it may only be written out by a later Write or Close.
+func (z *Writer) Write(p []byte) (int, error) {
+	if z.err != nil {
+		return 0, z.err
+	}
+	if z.err = z.writeHeader(); z.err != nil {
+		return 0, z.err
+	}
+	if z.parallel == nil {
+		z.digest.Write(p) // the parallelDeflater computes the CRC-32 of each chunk itself
+	}
+	z.size += uint32(len(p))
+	if z.err = z.deflater.write(p); z.err != nil {
+		return 0, z.err
+	}
+	return len(p), nil
+}
+
+// Close writes the rest of the compressed data and the trailer, it does not close the underlying io.Writer
+func (z *Writer) Close() error {
+	if z.err == errWriterClosed {
+		return nil
+	}
+	if z.err != nil {
+		return z.err
+	}
+	if z.err = z.writeHeader(); z.err != nil {
+		return z.err
+	}
+	if z.err = z.deflater.close(); z.err != nil {
+		return z.err
+	}
+	checksum := z.digest.Sum32()
+	if z.parallel != nil {
+		checksum = z.parallel.checksum
+	}
+	if z.err = writeGzipTrailer(z.writer, GzipTrailer{Crc32: checksum, Isize: z.size}); z.err != nil {
+		return z.err
+	}
+	if z.err = flushWriter(z.writer); z.err != nil {
+		return z.err
+	}
+	z.err = errWriterClosed
+	return nil
+}
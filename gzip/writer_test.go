@@ -0,0 +1,196 @@
+package gzip
+
+import (
+	"bytes"
+	stdgzip "compress/gzip"
+	"io"
+	"math/rand"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func compressForTest(t *testing.T, data []byte) []byte {
+	var compressed bytes.Buffer
+	writer := NewWriter(&compressed)
+	_, err := writer.Write(data)
+	assert.NoError(t, err)
+	assert.NoError(t, writer.Close())
+	return compressed.Bytes()
+}
+
+// testInputs returns the texts of the attachments, along with inputs hitting the edge cases of the encoder
+func testInputs() map[string][]byte {
+	inputs := map[string][]byte{
+		"empty":      {},
+		"one byte":   {'a'},
+		"repetitive": bytes.Repeat([]byte("abcabcabd"), 20000),
+		"zeros":      make([]byte, 100000),
+	}
+	random := make([]byte, 3*windowSize+123)
+	rand.New(rand.NewSource(1)).Read(random)
+	inputs["random"] = random
+	for _, name := range []string{"let_it_be.txt", "feynman.txt", "shakespare.txt"} {
+		data, err := os.ReadFile("../attachment/" + name)
+		if err != nil {
+			panic(err)
+		}
+		inputs[name] = data
+	}
+	return inputs
+}
+
+func TestWriterRoundTrip(t *testing.T) {
+	for name, data := range testInputs() {
+		t.Run(name, func(t *testing.T) {
+			compressed := compressForTest(t, data)
+
+			out, err := readGzipFile(bytes.NewReader(compressed))
+			assert.NoError(t, err)
+			assert.Equal(t, len(data), len(out))
+			assert.True(t, bytes.Equal(data, out))
+
+			// the standard library has to accept it as well
+			stdReader, err := stdgzip.NewReader(bytes.NewReader(compressed))
+			assert.NoError(t, err)
+			out, err = io.ReadAll(stdReader)
+			assert.NoError(t, err)
+			assert.True(t, bytes.Equal(data, out))
+		})
+	}
+}
+
+func TestWriterCompresses(t *testing.T) {
+	data, err := os.ReadFile("../attachment/feynman.txt")
+	if err != nil {
+		panic(err)
+	}
+	compressed := compressForTest(t, data)
+	t.Logf("%d bytes compressed to %d", len(data), len(compressed))
+	assert.Less(t, len(compressed), len(data)/2)
+}
+
+func TestWriterHeader(t *testing.T) {
+	var compressed bytes.Buffer
+	writer := NewWriter(&compressed)
+	writer.Header.Fname = []byte("let_it_be.txt")
+	writer.Header.Fcomment = []byte("lyrics")
+	writer.Header.Extra = []byte{'A', 'B', 1, 0, 'x'}
+	writer.Header.Header.Mtime = [4]byte{1, 2, 3, 4}
+	_, err := writer.Write([]byte("let it be"))
+	assert.NoError(t, err)
+	assert.NoError(t, writer.Close())
+	assert.NoError(t, writer.Close())
+	_, err = writer.Write([]byte("more"))
+	assert.Error(t, err)
+
+	reader, err := NewReader(&compressed)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("let_it_be.txt"), reader.Header.Fname)
+	assert.Equal(t, []byte("lyrics"), reader.Header.Fcomment)
+	assert.Equal(t, []byte{'A', 'B', 1, 0, 'x'}, reader.Header.Extra)
+	assert.Equal(t, [4]byte{1, 2, 3, 4}, reader.Header.Header.Mtime)
+	assert.Equal(t, byte(255), reader.Header.Header.OS)
+	out, err := io.ReadAll(reader)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("let it be"), out)
+
+	writer = NewWriter(io.Discard)
+	writer.Header.Fname = []byte("a\x00b")
+	assert.Error(t, writer.Close())
+}
+
+func TestWriterSmallWrites(t *testing.T) {
+	data, err := os.ReadFile("../attachment/feynman.txt")
+	if err != nil {
+		panic(err)
+	}
+	var compressed bytes.Buffer
+	writer := NewWriter(&compressed)
+	for i := 0; i < len(data); i += 7 {
+		end := i + 7
+		if end > len(data) {
+			end = len(data)
+		}
+		_, err := writer.Write(data[i:end])
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, writer.Close())
+	// splitting the input doesn't change the matches found
+	assert.Equal(t, compressForTest(t, data), compressed.Bytes())
+}
+
+func TestWriterLevelsAndStrategies(t *testing.T) {
+	feynman, err := os.ReadFile("../attachment/feynman.txt")
+	if err != nil {
+		panic(err)
+	}
+	strategies := []Strategy{DefaultStrategy, Filtered, HuffmanOnly, RLE, Stored}
+	sizes := map[Strategy][]int{}
+	for _, strategy := range strategies {
+		for level := NoCompression; level <= BestCompression; level++ {
+			for name, data := range testInputs() {
+				var compressed bytes.Buffer
+				writer, err := NewWriterLevelStrategy(&compressed, level, strategy)
+				assert.NoError(t, err)
+				_, err = writer.Write(data)
+				assert.NoError(t, err)
+				assert.NoError(t, writer.Close())
+
+				stdReader, err := stdgzip.NewReader(bytes.NewReader(compressed.Bytes()))
+				assert.NoError(t, err)
+				out, err := io.ReadAll(stdReader)
+				assert.NoError(t, err, "level %d, strategy %d, %s", level, strategy, name)
+				assert.True(t, bytes.Equal(data, out), "level %d, strategy %d, %s", level, strategy, name)
+
+				out, err = readGzipFile(bytes.NewReader(compressed.Bytes()))
+				assert.NoError(t, err)
+				assert.True(t, bytes.Equal(data, out), "level %d, strategy %d, %s", level, strategy, name)
+
+				if bytes.Equal(data, feynman) {
+					sizes[strategy] = append(sizes[strategy], compressed.Len())
+				}
+			}
+		}
+	}
+	t.Logf("compressed sizes of feynman.txt for levels 0-9: %v", sizes)
+
+	// level 0 stores the data, like the Stored strategy
+	assert.Equal(t, sizes[Stored][0], sizes[DefaultStrategy][0])
+	assert.Greater(t, sizes[Stored][0], len(feynman))
+	assert.Less(t, sizes[DefaultStrategy][BestCompression], sizes[DefaultStrategy][BestSpeed])
+	// on text, not using back-pointers costs a lot
+	assert.Greater(t, sizes[HuffmanOnly][6], sizes[DefaultStrategy][6])
+	assert.Greater(t, sizes[RLE][6], sizes[DefaultStrategy][6])
+}
+
+func TestWriterRLE(t *testing.T) {
+	// a "picture" with runs of the same byte
+	var data []byte
+	for i := 0; i < 1000; i++ {
+		data = append(data, bytes.Repeat([]byte{byte(i % 7)}, i%50+1)...)
+	}
+	rle, err := NewWriterLevelStrategy(io.Discard, DefaultCompression, RLE)
+	assert.NoError(t, err)
+	_, err = rle.Write(data)
+	assert.NoError(t, err)
+	assert.NoError(t, rle.Close())
+
+	huffmanOnly, err := NewWriterLevelStrategy(io.Discard, DefaultCompression, HuffmanOnly)
+	assert.NoError(t, err)
+	_, err = huffmanOnly.Write(data)
+	assert.NoError(t, err)
+	assert.NoError(t, huffmanOnly.Close())
+
+	assert.Less(t, rle.writer.offset, huffmanOnly.writer.offset/4)
+}
+
+func TestWriterInvalidLevel(t *testing.T) {
+	_, err := NewWriterLevel(io.Discard, 11)
+	assert.Error(t, err)
+	_, err = NewWriterLevel(io.Discard, -2)
+	assert.Error(t, err)
+	_, err = NewWriterLevelStrategy(io.Discard, 6, Strategy(42))
+	assert.Error(t, err)
+}
@@ -0,0 +1,150 @@
+package gzip
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"hash/adler32"
+	"io"
+)
+
+// ZlibHeader is the 2 bytes header of a zlib stream (RFC 1950)
+type ZlibHeader struct {
+	CMF byte // compression method (low 4 bits) and CINFO, the base-2 logarithm of the window size minus 8
+	FLG byte // FCHECK (low 5 bits), FDICT and FLEVEL (high 2 bits)
+}
+
+type ZlibMetaData struct {
+	Header ZlibHeader
+	DictID uint32 // Adler-32 of the preset dictionary, only present if FDICT is set
+}
+
+const FDICT byte = 0x20
+
+// WindowSize returns the size of the window the stream was compressed with, back-pointers can't reach further
+func (h ZlibHeader) WindowSize() int {
+	return 1 << (h.CMF>>4 + 8)
+}
+
+// Level returns FLEVEL: 0 is the fastest compression, 3 the slowest. It is informative only.
+func (h ZlibHeader) Level() int {
+	return int(h.FLG >> 6)
+}
+
+func readZlibMetaData(stream *bitstream) (ZlibMetaData, error) {
+	zlibMetaData := ZlibMetaData{}
+	if err := binary.Read(stream, binary.BigEndian, &zlibMetaData.Header); err != nil {
+		return zlibMetaData, decodeError(stream, err)
+	}
+	header := zlibMetaData.Header
+	if method := header.CMF & 0x0F; method != 8 {
+		return zlibMetaData, decodeError(stream, fmt.Errorf("%w: %d", ErrUnsupportedMethod, method))
+	}
+	// CMF and FLG, read as a 16 bits big-endian number, must be a multiple of 31 (FCHECK is there to make it so)
+	if (uint16(header.CMF)<<8|uint16(header.FLG))%31 != 0 {
+		return zlibMetaData, decodeError(stream, fmt.Errorf("%w: FCHECK doesn't match CMF %02x FLG %02x", ErrZlibHeader, header.CMF, header.FLG))
+	}
+	if cinfo := header.CMF >> 4; cinfo > 7 {
+		return zlibMetaData, decodeError(stream, fmt.Errorf("%w: window size 2^%d is larger than 32 KiB", ErrZlibHeader, cinfo+8))
+	}
+	if (header.FLG & FDICT) != 0 {
+		if err := binary.Read(stream, binary.BigEndian, &zlibMetaData.DictID); err != nil {
+			return zlibMetaData, decodeError(stream, err)
+		}
+	}
+	return zlibMetaData, nil
+}
+
+// readZlibTrailer reads the Adler-32 of the uncompressed data, which follows the deflate stream
+func readZlibTrailer(stream *bitstream) (uint32, error) {
+	alignToByte(stream)
+	var checksum uint32
+	if err := binary.Read(stream, binary.BigEndian, &checksum); err != nil {
+		return 0, decodeError(stream, err)
+	}
+	return checksum, nil
+}
+
+// ZlibReader is an io.Reader that can be read to retrieve the uncompressed data of a zlib stream (as found in PNG
+// files, git objects or HTTP "deflate" bodies).
+type ZlibReader struct {
+	Header ZlibMetaData
+
+	stream   *bitstream
+	decoder  *Decoder
+	inflater *inflater
+	digest   hash.Hash32 // Adler-32 of the data, so far
+	err      error
+}
+
+// NewZlibReader creates a new ZlibReader reading the zlib stream from r.
+// The header is read right away, so it is available in Header.
+func NewZlibReader(r io.Reader) (*ZlibReader, error) {
+	return NewZlibReaderDict(r, nil)
+}
+
+// NewZlibReaderDict is like NewZlibReader, but uses dict as the preset dictionary if the stream requires one
+// (FDICT is set). The dictionary is identified by its Adler-32, which has to match the DICTID of the header.
+func NewZlibReaderDict(r io.Reader, dict []byte) (*ZlibReader, error) {
+	return newZlibReader(r, dict, &Decoder{})
+}
+
+func newZlibReader(r io.Reader, dict []byte, decoder *Decoder) (*ZlibReader, error) {
+	z := &ZlibReader{
+		stream:  &bitstream{source: r},
+		decoder: decoder,
+		digest:  adler32.New(),
+	}
+	var err error
+	if z.Header, err = readZlibMetaData(z.stream); err != nil {
+		return nil, err
+	}
+	z.inflater = newInflater(z.stream, decoder)
+	z.inflater.maxDist = z.Header.Header.WindowSize()
+	if (z.Header.Header.FLG & FDICT) != 0 {
+		if dict == nil {
+			return nil, decodeError(z.stream, fmt.Errorf("%w: dictionary id %08x", ErrDictionary, z.Header.DictID))
+		}
+		if id := adler32.Checksum(dict); id != z.Header.DictID {
+			return nil, decodeError(z.stream, fmt.Errorf("%w: dictionary id %08x, expected %08x", ErrDictionary, id, z.Header.DictID))
+		}
+		z.inflater.window.preset(dict)
+	} else {
+		dict = nil // not used by the stream
+	}
+	decoder.trace(MemberStartEvent{Zlib: &z.Header, Dictionary: dict})
+	return z, nil
+}
+
+// Read inflates the data as it is being read, and verifies the Adler-32 of the trailer at the end of the stream.
+func (z *ZlibReader) Read(p []byte) (n int, err error) {
+	if z.err != nil {
+		return 0, z.err
+	}
+	n, err = z.inflater.Read(p)
+	z.digest.Write(p[:n])
+	if err == io.EOF {
+		err = z.verifyTrailer()
+	}
+	z.err = err
+	return n, err
+}
+
+func (z *ZlibReader) verifyTrailer() error {
+	checksum, err := readZlibTrailer(z.stream)
+	if err != nil {
+		return err
+	}
+	if sum := z.digest.Sum32(); sum != checksum {
+		return decodeError(z.stream, fmt.Errorf("%w: Adler-32 %08x, expected %08x", ErrChecksum, sum, checksum))
+	}
+	z.decoder.trace(MemberEndEvent{Size: z.inflater.pos, Verified: true})
+	return io.EOF
+}
+
+// Close releases the inflater, it does not close the underlying io.Reader
+func (z *ZlibReader) Close() error {
+	z.inflater = nil
+	z.err = errClosed
+	return nil
+}
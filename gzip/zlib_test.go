@@ -0,0 +1,116 @@
+package gzip
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestZlibReader(t *testing.T) {
+	expected, err := os.ReadFile("../attachment/let_it_be.txt")
+	if err != nil {
+		panic(err)
+	}
+	file, err := os.Open("../attachment/let_it_be.txt.zlib")
+	if err != nil {
+		panic(err)
+	}
+	defer file.Close()
+
+	reader, err := NewZlibReader(file)
+	assert.NoError(t, err)
+	assert.Equal(t, 1<<15, reader.Header.Header.WindowSize())
+	assert.Equal(t, 3, reader.Header.Header.Level())
+
+	out, err := io.ReadAll(reader)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, out)
+	assert.NoError(t, reader.Close())
+}
+
+func TestZlibReaderErrors(t *testing.T) {
+	original, err := os.ReadFile("../attachment/let_it_be.txt.zlib")
+	if err != nil {
+		panic(err)
+	}
+	withHeader := func(cmf, flg byte) []byte {
+		return append([]byte{cmf, flg}, original[2:]...)
+	}
+
+	testCases := []struct {
+		name     string
+		input    []byte
+		expected error
+	}{
+		{"method", withHeader(0x79, 0x18), ErrUnsupportedMethod},
+		{"fcheck", withHeader(0x78, 0xDB), ErrZlibHeader},
+		{"window size", withHeader(0x88, 0x1C), ErrZlibHeader},
+		{"dictionary", append(withHeader(0x78, 0xBB)[:2], 0, 0, 0, 1), ErrDictionary},
+		{"truncated", original[:1], ErrUnexpectedEOF},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := NewZlibReader(bytes.NewReader(tc.input))
+			assert.ErrorIs(t, err, tc.expected)
+		})
+	}
+}
+
+func TestZlibReaderWindowSize(t *testing.T) {
+	original, err := os.ReadFile("../attachment/let_it_be.txt.zlib")
+	if err != nil {
+		panic(err)
+	}
+	// declare a 256 bytes window, the lyrics repeat themselves further back than that
+	input := append([]byte{0x08, 0x1D}, original[2:]...)
+	reader, err := NewZlibReader(bytes.NewReader(input))
+	assert.NoError(t, err)
+	assert.Equal(t, 256, reader.Header.Header.WindowSize())
+	_, err = io.ReadAll(reader)
+	assert.ErrorIs(t, err, ErrInvalidDistance)
+}
+
+func TestZlibReaderChecksum(t *testing.T) {
+	original, err := os.ReadFile("../attachment/let_it_be.txt.zlib")
+	if err != nil {
+		panic(err)
+	}
+	corrupted := append([]byte{}, original...)
+	corrupted[len(corrupted)-1] ^= 0xFF
+	reader, err := NewZlibReader(bytes.NewReader(corrupted))
+	assert.NoError(t, err)
+	_, err = io.ReadAll(reader)
+	assert.ErrorIs(t, err, ErrChecksum)
+
+	reader, err = NewZlibReader(bytes.NewReader(original[:len(original)-2]))
+	assert.NoError(t, err)
+	_, err = io.ReadAll(reader)
+	assert.ErrorIs(t, err, ErrUnexpectedEOF)
+}
+
+func TestZlibReaderDict(t *testing.T) {
+	expected, err := os.ReadFile("../attachment/let_it_be.txt")
+	if err != nil {
+		panic(err)
+	}
+	compressed, err := os.ReadFile("../attachment/let_it_be_dict.txt.zlib")
+	if err != nil {
+		panic(err)
+	}
+	dict := []byte("Let it be, let it be\nWhisper words of wisdom, let it be\n")
+
+	reader, err := NewZlibReaderDict(bytes.NewReader(compressed), dict)
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(0x1a9b12e1), reader.Header.DictID)
+	out, err := io.ReadAll(reader)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, out)
+
+	_, err = NewZlibReader(bytes.NewReader(compressed))
+	assert.ErrorIs(t, err, ErrDictionary)
+	_, err = NewZlibReaderDict(bytes.NewReader(compressed), dict[1:])
+	assert.ErrorIs(t, err, ErrDictionary)
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"gzip.go/gzip"
+)
+
+/*
+Testing (-t) inflates every member of the files and checks everything that can be checked: the CRC-16 of the
+headers (FHCRC), the CRC-32 and ISIZE of the trailers, and that nothing but zeros (the padding of a tape block)
+follows the last member. The data is thrown away as it is inflated, so any size of file can be tested.
+
+With -p n, n files are tested at once (each one is still inflated sequentially), but the results are reported in
+the order of the files.
+*/
+
+var errDirectory = errors.New("is a directory -- ignored")
+
+// testFiles tests the files, with as many goroutines as -p says
+func testFiles(files []string) {
+	results := make([]chan error, len(files))
+	semaphore := make(chan struct{}, workers)
+	for i, name := range files {
+		results[i] = make(chan error, 1)
+		go func(name string, result chan<- error) {
+			semaphore <- struct{}{}
+			result <- testFile(name)
+			<-semaphore
+		}(name, results[i])
+	}
+	for i, name := range files {
+		reportTest(name, <-results[i])
+	}
+}
+
+// testFile inflates the file without writing the data anywhere, and returns the first error found
+func testFile(name string) error {
+	if name == "-" && !force && isTerminal(os.Stdin) {
+		return errors.New("compressed data not read from a terminal. Use -f to force decompression.")
+	}
+	in, err := openInput(name)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	if info, err := in.Stat(); err == nil && info.IsDir() {
+		return errDirectory
+	}
+
+	if format != "gzip" {
+		_, err := decompressTo(io.Discard, in, &gzip.Decoder{})
+		return err
+	}
+	members, err := gzip.ListMembers(in)
+	if err == nil || errors.Is(err, gzip.ErrTrailingGarbage) {
+		return err
+	}
+	// the error (a DecodeError) has its offset, the start of its member helps to make sense of it
+	start := int64(0)
+	if len(members) > 0 {
+		start = members[len(members)-1].Offset + members[len(members)-1].CompressedSize
+	}
+	return fmt.Errorf("member %d at offset %d: %w", len(members)+1, start, err)
+}
+
+// reportTest reports the result of testFile: trailing garbage is only a warning, like in gzip
+func reportTest(name string, err error) {
+	if name == "-" {
+		name = "stdin"
+	}
+	switch {
+	case err == nil:
+		if verbose {
+			fmt.Fprintf(os.Stderr, "%s:\t OK\n", name)
+		}
+	case err == errDirectory:
+		warn(name, err.Error())
+	case ignoreTrailingGarbage(name, err) == nil:
+	default:
+		fail(name, err)
+	}
+}
+
+// ignoreTrailingGarbage turns trailing garbage into a warning, the data before it is complete and verified
+func ignoreTrailingGarbage(name string, err error) error {
+	var decodeErr *gzip.DecodeError
+	if !errors.As(err, &decodeErr) || !errors.Is(err, gzip.ErrTrailingGarbage) {
+		return err
+	}
+	warn(name, fmt.Sprintf("decompression OK, trailing garbage at offset %d ignored", decodeErr.Offset))
+	return nil
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"gzip.go/gzip"
+)
+
+func TestTestFile(t *testing.T) {
+	first, err := os.ReadFile("attachment/feynman.txt.gz")
+	assert.NoError(t, err)
+	second, err := os.ReadFile("attachment/let_it_be.txt.gz")
+	assert.NoError(t, err)
+	multi := append(append([]byte{}, first...), second...)
+	dir := t.TempDir()
+
+	write := func(name string, data []byte) string {
+		path := filepath.Join(dir, name)
+		assert.NoError(t, os.WriteFile(path, data, 0600))
+		return path
+	}
+
+	assert.NoError(t, testFile(write("ok.gz", multi)))
+
+	err = testFile(write("garbage.gz", append(append([]byte{}, multi...), "junk"...)))
+	assert.ErrorIs(t, err, gzip.ErrTrailingGarbage)
+
+	corrupted := append([]byte{}, multi...)
+	corrupted[len(corrupted)-5] ^= 0xff // in the CRC-32 of the second member
+	err = testFile(write("corrupted.gz", corrupted))
+	assert.ErrorIs(t, err, gzip.ErrChecksum)
+	assert.Contains(t, err.Error(), "member 2 at offset 13337")
+
+	assert.Equal(t, errDirectory, testFile(dir))
+}
+
+func TestTestFilesConcurrently(t *testing.T) {
+	compressed, err := os.ReadFile("attachment/let_it_be.txt.zlib")
+	assert.NoError(t, err)
+	dir := t.TempDir()
+	var files []string
+	for i := 0; i < 8; i++ {
+		files = append(files, filepath.Join(dir, fmt.Sprintf("%d.zlib", i)))
+		assert.NoError(t, os.WriteFile(files[i], compressed, 0600))
+	}
+
+	// every file gets its own Decoder (run with -race)
+	format, workers, exitCode = "zlib", 4, exitOK
+	defer func() { format, workers, exitCode = "gzip", 1, exitOK }()
+	testFiles(files)
+	assert.Equal(t, exitOK, exitCode)
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"time"
+
+	"gzip.go/gzip"
+)
+
+/*
+Listing (-l) inflates each file to find its members, without writing the data anywhere:
+
+	-l          one line per file, like gzip -l: compressed and uncompressed sizes, ratio, name
+	-lv         one line per member, with the method, CRC-32 and modification time as well (like gzip -lv)
+	-l --json   everything about every member, as a JSON array of files, printed once all of them are read
+
+The uncompressed size is the size of the inflated data, which is right even for members over 4 GiB (ISIZE is the
+size modulo 2^32, the JSON has both).
+*/
+
+var jsonOutput bool
+
+// listedMember is a member of a file, as printed by --json
+type listedMember struct {
+	Offset       int64   `json:"offset"`
+	Compressed   int64   `json:"compressed"`
+	Uncompressed int64   `json:"uncompressed"`
+	Isize        uint32  `json:"isize"`
+	Ratio        float64 `json:"ratio"`
+	CRC32        string  `json:"crc32"`
+	Method       string  `json:"method"`
+	Name         string  `json:"name,omitempty"`
+	Comment      string  `json:"comment,omitempty"`
+	ModTime      string  `json:"mtime,omitempty"`
+	OS           string  `json:"os"`
+}
+
+// listedFile is a file, as printed by --json. If it couldn't be read to the end, it has the members before the error.
+type listedFile struct {
+	File         string         `json:"file"`
+	Compressed   int64          `json:"compressed"`
+	Uncompressed int64          `json:"uncompressed"`
+	Ratio        float64        `json:"ratio"`
+	Members      []listedMember `json:"members"`
+	Error        string         `json:"error,omitempty"`
+}
+
+var listing []listedFile
+var listedHeader bool
+var totalCompressed, totalUncompressed int64
+
+func listFile(name string) error {
+	in, err := openInput(name)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	members, err := gzip.ListMembers(in)
+
+	file := listedFile{File: name, Members: []listedMember{}}
+	for _, member := range members {
+		header := gzip.DecodeHeader(member.Header)
+		listed := listedMember{
+			Offset:       member.Offset,
+			Compressed:   member.CompressedSize,
+			Uncompressed: member.Size,
+			Isize:        member.Trailer.Isize,
+			Ratio:        roundedRatio(member.CompressedSize, member.Size),
+			CRC32:        fmt.Sprintf("%08x", member.Trailer.Crc32),
+			Method:       methodName(member.Header.Header.CompressionMethod),
+			Name:         header.Name,
+			Comment:      header.Comment,
+			OS:           header.OS.String(),
+		}
+		if !header.ModTime.IsZero() {
+			listed.ModTime = header.ModTime.UTC().Format(time.RFC3339)
+		}
+		file.Members = append(file.Members, listed)
+		file.Compressed += member.CompressedSize
+		file.Uncompressed += member.Size
+	}
+	file.Ratio = roundedRatio(file.Compressed, file.Uncompressed)
+	totalCompressed += file.Compressed
+	totalUncompressed += file.Uncompressed
+
+	if jsonOutput {
+		if err != nil {
+			file.Error = err.Error()
+		}
+		listing = append(listing, file)
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	uncompressedName, ok := stripSuffix(name)
+	if !ok {
+		uncompressedName = name
+	}
+	printListHeader()
+	if !verbose {
+		fmt.Printf("%19d %19d %5.1f%% %s\n", file.Compressed, file.Uncompressed, ratio(file.Compressed, file.Uncompressed), uncompressedName)
+		return nil
+	}
+	for i, member := range members {
+		header := gzip.DecodeHeader(member.Header)
+		date := "-"
+		if !header.ModTime.IsZero() {
+			date = header.ModTime.Format("Jan _2 15:04")
+		}
+		memberName := header.Name
+		if memberName == "" {
+			memberName = uncompressedName
+		}
+		fmt.Printf("%-6.5s %s %12s %19d %19d %5.1f%% %s\n", file.Members[i].Method, file.Members[i].CRC32, date,
+			member.CompressedSize, member.Size, ratio(member.CompressedSize, member.Size), memberName)
+	}
+	return nil
+}
+
+func printListHeader() {
+	if listedHeader {
+		return
+	}
+	listedHeader = true
+	if verbose {
+		fmt.Printf("%-6s %-8s %12s %19s %19s %6s %s\n", "method", "crc", "date  time", "compressed", "uncompressed", "ratio", "uncompressed_name")
+	} else {
+		fmt.Printf("%19s %19s %6s %s\n", "compressed", "uncompressed", "ratio", "uncompressed_name")
+	}
+}
+
+// finishListing prints the totals when several files were listed, or the whole JSON listing
+func finishListing(files int) {
+	if jsonOutput {
+		if listing == nil {
+			listing = []listedFile{}
+		}
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		encoder.Encode(listing)
+		return
+	}
+	if files < 2 {
+		return
+	}
+	printListHeader()
+	if verbose {
+		fmt.Printf("%-6s %-8s %12s ", "", "", "")
+	}
+	fmt.Printf("%19d %19d %5.1f%% (totals)\n", totalCompressed, totalUncompressed, ratio(totalCompressed, totalUncompressed))
+}
+
+func methodName(method byte) string {
+	if method == 8 {
+		return "deflate"
+	}
+	return fmt.Sprintf("method %d", method)
+}
+
+// roundedRatio is the ratio of the JSON listing, to a tenth of a percent like the text one
+func roundedRatio(compressed, uncompressed int64) float64 {
+	return math.Round(ratio(compressed, uncompressed)*10) / 10
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListFileJSON(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "multi.gz")
+	first, err := os.ReadFile("attachment/feynman.txt.gz")
+	assert.NoError(t, err)
+	second, err := os.ReadFile("attachment/let_it_be.txt.gz")
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(name, append(append([]byte{}, first...), second...), 0600))
+
+	jsonOutput, listing = true, nil
+	defer func() { jsonOutput, listing = false, nil }()
+	assert.NoError(t, listFile(name))
+
+	assert.Len(t, listing, 1)
+	file := listing[0]
+	assert.Equal(t, int64(len(first)+len(second)), file.Compressed)
+	assert.Equal(t, int64(37106+1100), file.Uncompressed)
+	assert.Len(t, file.Members, 2)
+	assert.Equal(t, "feynman.txt", file.Members[0].Name)
+	assert.Equal(t, "10343415", file.Members[0].CRC32)
+	assert.Equal(t, "deflate", file.Members[0].Method)
+	assert.Equal(t, int64(len(first)), file.Members[1].Offset)
+	assert.Equal(t, "let_it_be.txt", file.Members[1].Name)
+	assert.Equal(t, uint32(1100), file.Members[1].Isize)
+	assert.Empty(t, file.Error)
+
+	// a truncated file is listed up to the error
+	assert.NoError(t, os.WriteFile(name, append(append([]byte{}, first...), second[:100]...), 0600))
+	assert.Error(t, listFile(name))
+	assert.Len(t, listing, 2)
+	assert.Len(t, listing[1].Members, 1)
+	assert.NotEmpty(t, listing[1].Error)
+}
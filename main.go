@@ -1,34 +1,731 @@
 package main
 
 import (
-	"flag"
+	"bufio"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/term"
+
+	"gzip.go/gzip"
 )
 
-var (
-	slowPrintMode   = false
-	backPointerMode = false
-	explanationMode = false
-	fileName        string
+// Exit codes of gzip: a warning is e.g. a file skipped because it has the wrong suffix or its output already exists
+const (
+	exitOK      = 0
+	exitError   = 1
+	exitWarning = 2
 )
 
-var literalCount, backPointerCount, totalBytes int
+var programName = "gzip"
+var exitCode = exitOK
+
+var decompress bool
+var toStdout bool
+var force bool
+var keep bool
+var list bool
+var test bool
+var quiet bool
+var verbose bool
+var noName bool
+var restoreName bool
+var suffix = ".gz"
+var format = "gzip"
+var dictName string
+var level = gzip.DefaultCompression
+var strategy = "default"
+var workers = 1
+var bgzf bool
+var trace bool
+var decoder gzip.Decoder // of --trace, its statistics add up over the files
+var tracer gzip.TextTracer
+var buildIndex bool
+var span int64 = 1 << 20
+var readAt bool // --at was given
+var readOffset int64
+var readLength int64 = 1024
+
+var strategies = map[string]gzip.Strategy{
+	"default":  gzip.DefaultStrategy,
+	"filtered": gzip.Filtered,
+	"huffman":  gzip.HuffmanOnly,
+	"rle":      gzip.RLE,
+	"stored":   gzip.Stored,
+}
+
+func boolValue(p *bool) func(string) error {
+	return func(string) error {
+		*p = true
+		return nil
+	}
+}
+
+func intValue(p *int) func(string) error {
+	return func(value string) error {
+		n, err := strconv.Atoi(value)
+		*p = n
+		return err
+	}
+}
+
+func int64Value(p *int64) func(string) error {
+	return func(value string) error {
+		n, err := strconv.ParseInt(value, 10, 64)
+		*p = n
+		return err
+	}
+}
+
+func stringValue(p *string) func(string) error {
+	return func(value string) error {
+		*p = value
+		return nil
+	}
+}
+
+func setLevel(l int) func(string) error {
+	return func(string) error {
+		level = l
+		return nil
+	}
+}
+
+// setName handles -n and -N, the last one wins
+func setName(save bool) func(string) error {
+	return func(string) error {
+		noName, restoreName = !save, save
+		return nil
+	}
+}
+
+// setAt handles --at, whose offset can't tell by itself whether it was given
+func setAt(value string) error {
+	readAt = true
+	return int64Value(&readOffset)(value)
+}
+
+var options = []option{
+	{short: 'c', long: "stdout", usage: "write on standard output, keep the original files", set: boolValue(&toStdout)},
+	{short: 'd', long: "decompress", usage: "decompress", set: boolValue(&decompress)},
+	{long: "uncompress", set: boolValue(&decompress)},
+	{short: 'f', long: "force", usage: "overwrite existing output files, compress files with the suffix already", set: boolValue(&force)},
+	{short: 'h', long: "help", usage: "print this help", set: func(string) error { return errHelp }},
+	{short: 'k', long: "keep", usage: "keep (don't delete) the input files", set: boolValue(&keep)},
+	{short: 'l', long: "list", usage: "list the sizes of the compressed files (of each member, with -v)", set: boolValue(&list)},
+	{long: "json", usage: "list the members of the files as JSON (implies --list)", set: boolValue(&jsonOutput)},
+	{short: 't', long: "test", usage: "test the integrity of the compressed files", set: boolValue(&test)},
+	{short: 'q', long: "quiet", usage: "suppress the warnings", set: boolValue(&quiet)},
+	{short: 'v', long: "verbose", usage: "print the name and ratio of each file", set: boolValue(&verbose)},
+	{short: 'n', long: "no-name", usage: "don't save the name and time of the file when compressing (nor restore them)", set: setName(false)},
+	{short: 'N', long: "name", usage: "save the name and time of the file when compressing, restore them when decompressing", set: setName(true)},
+	{short: 'S', long: "suffix", hasValue: true, valueName: "suf", usage: "use the suffix SUF instead of .gz", set: stringValue(&suffix)},
+	{short: '1', long: "fast", usage: "compress faster", set: setLevel(1)},
+	{short: '2', set: setLevel(2)},
+	{short: '3', set: setLevel(3)},
+	{short: '4', set: setLevel(4)},
+	{short: '5', set: setLevel(5)},
+	{short: '6', set: setLevel(6)},
+	{short: '7', set: setLevel(7)},
+	{short: '8', set: setLevel(8)},
+	{short: '9', long: "best", usage: "compress better", set: setLevel(9)},
+	{long: "level", hasValue: true, valueName: "n", usage: "compression level, 0 to 10 (10 is optimal parsing, really slow)", set: intValue(&level)},
+	{long: "strategy", hasValue: true, valueName: "s", usage: "default, filtered, huffman, rle or stored", set: stringValue(&strategy)},
+	{short: 'p', long: "processes", hasValue: true, valueName: "n", usage: "compress with n goroutines, or test n files at once", set: intValue(&workers)},
+	{long: "bgzf", usage: "compress into a BGZF file (blocked gzip, as used by samtools and tabix)", set: boolValue(&bgzf)},
+	{long: "format", hasValue: true, valueName: "f", usage: "gzip, zlib or raw: container of the deflate stream, when decompressing", set: stringValue(&format)},
+	{long: "dict", hasValue: true, valueName: "file", usage: "preset dictionary of zlib and raw streams", set: stringValue(&dictName)},
+	{long: "trace", usage: "print the data as it is inflated, and a summary of its literals and back-pointers", set: boolValue(&trace)},
+	{short: 'e', long: "explain", usage: "explain the structure of the stream (implies --trace)", set: boolValue(&tracer.Explain)},
+	{short: 's', long: "slow", usage: "print slowly (implies --trace)", set: boolValue(&tracer.Slow)},
+	{long: "back-pointers", usage: "print the back-pointers as <source,length>(...) (implies --trace)", set: boolValue(&tracer.BackPointers)},
+	{long: "index", usage: "write an index of the gzip file into FILE.gzidx, for random access", set: boolValue(&buildIndex)},
+	{long: "span", hasValue: true, valueName: "n", usage: "bytes of uncompressed data between two access points of the index", set: int64Value(&span)},
+	{long: "at", hasValue: true, valueName: "offset", usage: "print the data at an uncompressed offset, using the index", set: setAt},
+	{long: "length", hasValue: true, valueName: "n", usage: "number of bytes to print, with --at", set: int64Value(&readLength)},
+}
+
+var errHelp = errors.New("help requested")
 
 func main() {
-	literalCount, backPointerCount, totalBytes = 0, 0, 0
+	os.Exit(run(os.Args))
+}
+
+func run(args []string) int {
+	// like gzip, the program decompresses when it is called (or linked as) gunzip or zcat
+	switch strings.TrimSuffix(filepath.Base(args[0]), ".exe") {
+	case "gunzip":
+		decompress = true
+	case "zcat", "gzcat":
+		decompress, toStdout = true, true
+	}
+	files, err := parseArgs(args[1:], options)
+	if err == errHelp {
+		fmt.Printf("Usage: %s [OPTION]... [FILE]...\n", programName)
+		fmt.Println("Compress or uncompress FILEs (by default, compress FILES in-place).")
+		fmt.Println()
+		printUsage(os.Stdout, options)
+		fmt.Println()
+		fmt.Println("With no FILE, or when FILE is -, read standard input.")
+		return exitOK
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", programName, err)
+		fmt.Fprintf(os.Stderr, "Try '%s --help' for more information.\n", programName)
+		return exitError
+	}
+	if err := checkOptions(); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", programName, err)
+		return exitError
+	}
 
-	flag.StringVar(&fileName, "f", "", "-f [path to file name]")
-	flag.BoolVar(&slowPrintMode, "s", false, "-s to enable slow print mode")
-	flag.BoolVar(&explanationMode, "e", false, "-e to enable explanation")
-	flag.BoolVar(&backPointerMode, "bp", false, "-bp to enable back pointer (only effective in slow print mode")
-	flag.Parse()
+	if buildIndex || readAt {
+		if len(files) != 1 {
+			fmt.Fprintf(os.Stderr, "%s: --index and --at need exactly one file\n", programName)
+			return exitError
+		}
+		if err := indexCommand(files[0]); err != nil {
+			fail(files[0], err)
+		}
+		return exitCode
+	}
 
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+	if test {
+		testFiles(files)
+		return exitCode
+	}
+	for _, name := range files {
+		processFile(name)
+	}
+	if list {
+		finishListing(len(files))
+	}
+	return exitCode
+}
+
+func checkOptions() error {
+	if _, ok := strategies[strategy]; !ok {
+		return fmt.Errorf("unknown strategy %q", strategy)
+	}
+	if suffix == "" {
+		return fmt.Errorf("the suffix can't be empty")
+	}
+	if workers < 1 {
+		return fmt.Errorf("invalid -p %d, at least one goroutine is needed", workers)
+	}
+	if span <= 0 {
+		return fmt.Errorf("invalid --span %d, it must be positive", span)
+	}
+	if readAt && readOffset < 0 {
+		return fmt.Errorf("invalid --at %d, it can't be negative", readOffset)
+	}
+	if readLength <= 0 {
+		return fmt.Errorf("invalid --length %d, it must be positive", readLength)
+	}
+	if tracer.Explain || tracer.Slow || tracer.BackPointers {
+		trace = true
+	}
+	if jsonOutput {
+		list = true
+	}
+	if list && format != "gzip" {
+		return fmt.Errorf("only gzip files can be listed")
+	}
+	// listing, testing and tracing only read the compressed files
+	if list || test || trace {
+		decompress = true
+	}
+	// the BGZF writer compresses each block on its own, with the default strategy and a single goroutine
+	if bgzf && !decompress && strategy != "default" {
+		return fmt.Errorf("--strategy can't be used with --bgzf")
+	}
+	if bgzf && !decompress && workers > 1 {
+		return fmt.Errorf("-p can't be used with --bgzf")
+	}
+	return nil
+}
+
+// fail reports an error on a file, the exit code will be 1
+func fail(name string, err error) {
+	var pathErr *os.PathError
+	if errors.As(err, &pathErr) && pathErr.Path == name {
+		err = pathErr.Err // "gzip: x: no such file or directory" rather than "gzip: x: open x: no such..."
+	}
+	fmt.Fprintf(os.Stderr, "%s: %s: %v\n", programName, name, err)
+	exitCode = exitError
+}
+
+// warn reports a file that is skipped, the exit code will be 2 (unless there is an error)
+func warn(name string, message string) {
+	if !quiet {
+		fmt.Fprintf(os.Stderr, "%s: %s: %s\n", programName, name, message)
+	}
+	if exitCode == exitOK {
+		exitCode = exitWarning
+	}
+}
+
+// isTerminal tells whether the file is a terminal. Not just a character device: /dev/null is one too, and
+// "gzip -c file > /dev/null" is fine.
+func isTerminal(file *os.File) bool {
+	return term.IsTerminal(int(file.Fd()))
+}
+
+func processFile(name string) {
+	if name == "-" {
+		processStdin()
+		return
+	}
+	info, err := os.Lstat(name)
+	if err != nil {
+		fail(name, err)
+		return
+	}
+	// in place, a symbolic link would be replaced by a compressed copy of its target (and a device or a pipe by a
+	// regular file): like gzip, they're skipped unless -f is given. Reading them (-c, -l) is fine.
+	inPlace := !toStdout && !list && !trace
+	if info.Mode()&os.ModeSymlink != 0 {
+		if inPlace && !force {
+			warn(name, "is a symbolic link -- ignored")
+			return
+		}
+		if info, err = os.Stat(name); err != nil {
+			fail(name, err)
+			return
+		}
+	}
+	if info.IsDir() {
+		warn(name, "is a directory -- ignored")
+		return
+	}
+	if !info.Mode().IsRegular() && inPlace && !force {
+		warn(name, "is not a regular file -- ignored")
+		return
+	}
+
+	switch {
+	case trace:
+		err = traceFile(name)
+	case list:
+		err = listFile(name)
+	case decompress:
+		err = decompressFile(name, info)
+	default:
+		err = compressFile(name, info)
+	}
+	if err != nil {
+		fail(name, err)
+	}
+}
+
+// writesToTerminal refuses to write compressed data to a terminal (without -f), it would only garble it
+func writesToTerminal() bool {
+	if decompress || force || !isTerminal(os.Stdout) {
+		return false
+	}
+	fmt.Fprintf(os.Stderr, "%s: compressed data not written to a terminal. Use -f to force compression.\n", programName)
+	exitCode = exitError
+	return true
+}
+
+func processStdin() {
+	if decompress && !force && isTerminal(os.Stdin) {
+		fmt.Fprintf(os.Stderr, "%s: compressed data not read from a terminal. Use -f to force decompression.\n", programName)
+		exitCode = exitError
+		return
+	}
+	if writesToTerminal() {
+		return
+	}
+	var err error
+	switch {
+	case trace:
+		err = traceFile("-")
+	case list:
+		err = listFile("-")
+	case decompress:
+		_, err = decompressTo(os.Stdout, os.Stdin, &gzip.Decoder{})
+		err = ignoreTrailingGarbage("stdin", err)
+	default:
+		_, err = compressTo(os.Stdout, os.Stdin, "", time.Time{})
+	}
+	if err != nil {
+		fail("stdin", err)
+	}
+}
+
+// openInput opens a file, or returns stdin for "-"
+func openInput(name string) (*os.File, error) {
+	if name == "-" {
+		return os.Stdin, nil
+	}
+	return os.Open(name)
+}
+
+func compressFile(name string, info os.FileInfo) error {
+	if !toStdout && !force && strings.HasSuffix(name, suffix) {
+		warn(name, "already has "+suffix+" suffix -- unchanged")
+		return nil
+	}
+	in, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	baseName, modTime := filepath.Base(name), info.ModTime()
+	if noName {
+		baseName, modTime = "", time.Time{}
+	}
+	if toStdout {
+		if writesToTerminal() {
+			return nil
+		}
+		_, err := compressTo(os.Stdout, in, baseName, modTime)
+		return err
+	}
+
+	outName := name + suffix
+	out, err := createOutput(outName)
+	if out == nil {
+		return err
+	}
+	size, err := compressTo(out, in, baseName, modTime)
+	return finishOutput(name, outName, out, info, info.ModTime(), info.Size(), size, err)
+}
+
+func decompressFile(name string, info os.FileInfo) error {
+	outName, ok := stripSuffix(name)
+	if !ok && !toStdout {
+		warn(name, "unknown suffix -- ignored")
+		return nil
+	}
+	in, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	reader, err := newReader(in, &gzip.Decoder{})
+	if err != nil {
+		return err
+	}
+	if toStdout {
+		_, err := writeInflated(os.Stdout, reader)
+		return ignoreTrailingGarbage(name, err)
+	}
+
+	modTime := info.ModTime()
+	if gzipReader, ok := reader.(*gzip.Reader); ok && restoreName {
+		// the header of the first member has been read already, the output is named after it
+		header := gzipReader.Header
+		if header.Fname != nil {
+			fname, err := safeName(header.Fname)
+			if err != nil {
+				return err
+			}
+			outName = filepath.Join(filepath.Dir(name), fname)
+		}
+		if mtime := gzip.DecodeHeader(header).ModTime; !mtime.IsZero() {
+			modTime = mtime
+		}
+	}
+	if outInfo, err := os.Stat(outName); err == nil && os.SameFile(info, outInfo) {
+		return fmt.Errorf("the name in the header is the name of the file itself")
+	}
+
+	out, err := createOutput(outName)
+	if out == nil {
+		return err
+	}
+	size, err := writeInflated(out, reader)
+	err = ignoreTrailingGarbage(name, err)
+	return finishOutput(name, outName, out, info, modTime, size, info.Size(), err)
+}
+
+// stripSuffix returns the name of the decompressed file: the name without the suffix of a compressed file
+func stripSuffix(name string) (string, bool) {
+	base := filepath.Base(name)
+	for _, s := range []string{suffix, ".gz", "-gz", ".z", "-z", "_z"} {
+		if len(base) > len(s) && strings.HasSuffix(base, s) {
+			return name[:len(name)-len(s)], true
+		}
+	}
+	for _, s := range []string{".tgz", ".taz"} {
+		if len(base) > len(s) && strings.HasSuffix(base, s) {
+			return name[:len(name)-len(s)] + ".tar", true
+		}
+	}
+	return "", false
+}
+
+// safeName checks the file name found in a header (FNAME), before a file is created with it: it must be a plain
+// name, not a path, or a hostile file could write anywhere (e.g. "../../.bashrc" or "/etc/passwd"). Like gzip, the
+// bytes of the name are used as they are.
+func safeName(fname []byte) (string, error) {
+	name := string(fname)
+	switch {
+	case name == "" || name == "." || name == "..":
+		return "", fmt.Errorf("invalid file name %q in the header", name)
+	case strings.ContainsAny(name, `/\`) || filepath.IsAbs(name) || filepath.VolumeName(name) != "":
+		return "", fmt.Errorf("file name %q in the header is a path, not a name", name)
+	}
+	return name, nil
+}
+
+// createOutput creates the output file, unless it exists already and -f isn't given: then it warns and returns nil
+func createOutput(name string) (*os.File, error) {
+	if force {
+		// the existing file is removed rather than truncated, so a symbolic link there isn't followed
+		if info, err := os.Lstat(name); err == nil && !info.IsDir() {
+			if err := os.Remove(name); err != nil {
+				return nil, err
+			}
+		}
+	}
+	// only the owner can read it until it's complete, it gets the mode of the input file then
+	out, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if os.IsExist(err) {
+		warn(name, "already exists; not overwritten")
+		return nil, nil
+	}
+	return out, err
+}
+
+// finishOutput closes the output file, and gives it the mode of the input and the modification time (the input's,
+// or the one of the header with -N). The input is deleted then (unless -k). If the compression failed, it deletes
+// the output instead.
+func finishOutput(name, outName string, out *os.File, info os.FileInfo, modTime time.Time, uncompressed, compressed int64, err error) error {
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(outName, info.Mode().Perm())
+	}
+	if err == nil {
+		err = os.Chtimes(outName, modTime, modTime)
+	}
+	if err != nil {
+		os.Remove(outName)
+		return err
+	}
+	if verbose {
+		action := "replaced with"
+		if keep {
+			action = "created"
+		}
+		fmt.Fprintf(os.Stderr, "%s:\t%5.1f%% -- %s %s\n", name, ratio(compressed, uncompressed), action, outName)
+	}
+	if !keep {
+		return os.Remove(name)
+	}
+	return nil
+}
+
+// ratio is the space saved by the compression, in percent
+func ratio(compressed, uncompressed int64) float64 {
+	if uncompressed == 0 {
+		return 0
+	}
+	return 100 * (1 - float64(compressed)/float64(uncompressed))
+}
+
+// countingWriter counts the bytes written through it
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// compressTo compresses in into out, with the name and modification time in the header (unless they're empty), and
+// returns the size of the compressed data
+func compressTo(out io.Writer, in io.Reader, name string, modTime time.Time) (int64, error) {
+	buffered := bufio.NewWriterSize(out, 64<<10)
+	counter := &countingWriter{w: buffered}
+
+	var writer io.WriteCloser
+	if bgzf {
+		bgzfWriter, err := gzip.NewBGZFWriterLevel(counter, level)
+		if err != nil {
+			return 0, err
+		}
+		writer = bgzfWriter
+	} else {
+		gzipWriter, err := gzip.NewWriterLevelStrategy(counter, level, strategies[strategy])
+		if err != nil {
+			return 0, err
+		}
+		header := gzip.DecodeHeader(gzipWriter.Header)
+		header.ModTime = modTime
+		// SetHeader only fails if the modification time doesn't fit in MTIME, it is left out then (like gzip does)
+		_ = gzipWriter.SetHeader(header)
+		if name != "" {
+			// gzip stores the bytes of the name as they are, whatever their encoding
+			gzipWriter.Header.Fname = []byte(name)
+		}
+		if workers > 1 {
+			if err := gzipWriter.SetConcurrency(0, workers); err != nil {
+				return 0, err
+			}
+		}
+		writer = gzipWriter
+	}
+	if _, err := io.Copy(writer, in); err != nil {
+		return counter.n, err
+	}
+	if err := writer.Close(); err != nil {
+		return counter.n, err
+	}
+	return counter.n, buffered.Flush()
+}
+
+// decompressTo inflates in into out with decoder, and returns the size of the inflated data
+func decompressTo(out io.Writer, in io.Reader, decoder *gzip.Decoder) (int64, error) {
+	reader, err := newReader(in, decoder)
+	if err != nil {
+		return 0, err
+	}
+	return writeInflated(out, reader)
+}
+
+// writeInflated copies what reader inflates into out, and returns its size
+func writeInflated(out io.Writer, reader io.Reader) (int64, error) {
+	buffered := bufio.NewWriterSize(out, 64<<10)
+	n, err := io.Copy(buffered, reader)
+	if err != nil {
+		return n, err
+	}
+	return n, buffered.Flush()
+}
+
+// newReader creates the reader of --format. A Decoder can't be shared by goroutines, so each one needs its own.
+func newReader(file io.Reader, decoder *gzip.Decoder) (io.Reader, error) {
+	var dict []byte
+	if dictName != "" {
+		var err error
+		if dict, err = os.ReadFile(dictName); err != nil {
+			return nil, err
+		}
+	}
+	switch format {
+	case "gzip":
+		return decoder.NewReader(file)
+	case "zlib":
+		return decoder.NewZlibReaderDict(file, dict)
+	case "raw":
+		return decoder.NewRawReaderDict(file, dict), nil
+	}
+	return nil, fmt.Errorf("unknown format %q", format)
+}
+
+// traceFile prints the data as it is inflated, with --explain, --slow and --back-pointers
+func traceFile(name string) error {
+	in, err := openInput(name)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	// the decoded text is printed as it is inflated, so the output of the reader itself is not needed
+	tracer.Inline = true
+	decoder.Tracer = &tracer
+	decoder.BitByBit = tracer.Explain // walking the trees is what the explanation is about
+	reader, err := newReader(in, &decoder)
+	if err == nil {
+		_, err = io.Copy(io.Discard, reader)
+	}
+	if err != nil {
+		fmt.Println()
+		return err
+	}
+
+	fmt.Printf("\n\nSummary Report: literalCount %d, backPointerCount %d, totalBytes %d\n", decoder.LiteralCount, decoder.BackPointerCount, decoder.TotalBytes)
+	return nil
+}
+
+// indexCommand writes the index of the file with --index, or prints a range of it with --at
+func indexCommand(fileName string) error {
 	file, err := os.Open(fileName)
 	if err != nil {
-		panic(err)
+		return err
 	}
-	_ = readGzipFile(file)
+	defer file.Close()
+	indexName := fileName + ".gzidx"
 
-	fmt.Printf("\n\nSummary Report: literalCount %d, backPointerCount %d, totalBytes %d\n", literalCount, backPointerCount, totalBytes)
+	if buildIndex {
+		index, err := gzip.BuildIndex(file, span)
+		if err != nil {
+			return err
+		}
+		out, err := os.Create(indexName)
+		if err != nil {
+			return err
+		}
+		defer out.Close()
+		if _, err := index.WriteTo(out); err != nil {
+			return err
+		}
+		fmt.Printf("%d access points for %d bytes written to %s\n", len(index.Points), index.Size, indexName)
+		return out.Close()
+	}
+
+	in, err := os.Open(indexName)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	index, err := gzip.ReadIndex(in)
+	if err != nil {
+		return err
+	}
+	// the range is printed in chunks, so that a long one doesn't have to fit in memory. Every ReadAt inflates from
+	// the access point before it, so a chunk is at least as long as the span (within reason).
+	end := readOffset + readLength
+	if end > index.Size || end < readOffset {
+		end = index.Size
+	}
+	chunk := index.Span
+	if chunk < 1<<20 {
+		chunk = 1 << 20
+	} else if chunk > 16<<20 {
+		chunk = 16 << 20
+	}
+	if chunk > end-readOffset {
+		chunk = end - readOffset
+	}
+	if chunk <= 0 {
+		return nil // past the end of the data
+	}
+	buf := make([]byte, chunk)
+	reader := gzip.NewIndexedReader(file, index)
+	for offset := readOffset; offset < end; {
+		length := end - offset
+		if length > chunk {
+			length = chunk
+		}
+		n, err := reader.ReadAt(buf[:length], offset)
+		if _, err := os.Stdout.Write(buf[:n]); err != nil {
+			return err
+		}
+		if err == io.EOF {
+			return nil // the data ended before the index said
+		}
+		if err != nil {
+			return err
+		}
+		offset += int64(n)
+	}
+	return nil
 }
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"gzip.go/gzip"
+)
+
+func TestStripSuffix(t *testing.T) {
+	testCases := []struct {
+		name     string
+		stripped string
+		ok       bool
+	}{
+		{"a.txt.gz", "a.txt", true},
+		{"dir.gz/a-gz", "dir.gz/a", true},
+		{"a.tgz", "a.tar", true},
+		{"a.txt", "", false},
+		{"dir/.gz", "", false},
+	}
+	for _, tc := range testCases {
+		stripped, ok := stripSuffix(tc.name)
+		assert.Equal(t, tc.ok, ok, tc.name)
+		assert.Equal(t, tc.stripped, stripped, tc.name)
+	}
+}
+
+func TestSafeName(t *testing.T) {
+	for _, name := range []string{"a.txt", "caf\xc3\xa9.txt", "..a", "a..b"} {
+		safe, err := safeName([]byte(name))
+		assert.NoError(t, err, name)
+		assert.Equal(t, name, safe)
+	}
+	for _, name := range []string{"", ".", "..", "../a", "a/b", "/etc/passwd", `..\a`, `C:\a`, "a/"} {
+		_, err := safeName([]byte(name))
+		assert.Error(t, err, name)
+	}
+}
+
+func TestDecompressFileRestoreName(t *testing.T) {
+	dir := t.TempDir()
+	modTime := time.Date(2020, 9, 13, 12, 26, 40, 0, time.UTC)
+	writeMember := func(path string, name string) os.FileInfo {
+		var out bytes.Buffer
+		writer := gzip.NewWriter(&out)
+		assert.NoError(t, writer.SetHeader(gzip.Header{Name: name, ModTime: modTime}))
+		_, err := writer.Write([]byte("hello"))
+		assert.NoError(t, err)
+		assert.NoError(t, writer.Close())
+		assert.NoError(t, os.WriteFile(path, out.Bytes(), 0600))
+		info, err := os.Stat(path)
+		assert.NoError(t, err)
+		return info
+	}
+
+	restoreName, keep = true, true
+	defer func() { restoreName, keep = false, false }()
+
+	path := filepath.Join(dir, "archive.gz")
+	assert.NoError(t, decompressFile(path, writeMember(path, "original.txt")))
+	info, err := os.Stat(filepath.Join(dir, "original.txt"))
+	assert.NoError(t, err)
+	assert.True(t, info.ModTime().Equal(modTime))
+	_, err = os.Stat(filepath.Join(dir, "archive"))
+	assert.True(t, os.IsNotExist(err))
+
+	// nothing is written outside of the directory
+	path = filepath.Join(dir, "hostile.gz")
+	assert.Error(t, decompressFile(path, writeMember(path, "../escaped.txt")))
+	_, err = os.Stat(filepath.Join(filepath.Dir(dir), "escaped.txt"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestProcessFileSkipsSymlinks(t *testing.T) {
+	dir := t.TempDir()
+	target, link := filepath.Join(dir, "target"), filepath.Join(dir, "link")
+	assert.NoError(t, os.WriteFile(target, []byte("hello"), 0600))
+	if err := os.Symlink(target, link); err != nil {
+		t.Skip("no symbolic links here:", err)
+	}
+
+	exitCode, quiet = exitOK, true
+	defer func() { exitCode, quiet = exitOK, false }()
+	processFile(link)
+	assert.Equal(t, exitWarning, exitCode)
+	_, err := os.Lstat(link)
+	assert.NoError(t, err)
+	_, err = os.Stat(link + ".gz")
+	assert.True(t, os.IsNotExist(err))
+}